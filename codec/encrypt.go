@@ -0,0 +1,261 @@
+package codec
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// EncryptionKeyEnv holds the name of the env variable with base64 encoded AES key.
+const EncryptionKeyEnv = "CONFIG_ENCRYPTION_KEY"
+
+// EncryptionKeyFileEnv holds the name of the env variable with path of the file that has base64 encoded AES key.
+const EncryptionKeyFileEnv = "CONFIG_ENCRYPTION_KEY_FILE"
+
+const (
+	encPrefix = "ENC["
+	encSuffix = "]"
+)
+
+// ErrNoDecrypter is returned when encrypted value's method is not in Decrypters.
+var ErrNoDecrypter = errors.New("decrypter not found")
+
+// ErrInvalidKeySize is returned when the AES256_GCM key is not 32 bytes.
+var ErrInvalidKeySize = errors.New("key must be 32 bytes")
+
+// Decrypter decrypts an encrypted value.
+//
+// Context is the context of the loader, it holds the environment source (see loader.WithEnvSource).
+type Decrypter interface {
+	Decrypt(ctx context.Context, value EncryptedValue) (string, error)
+}
+
+// Decrypters is a map of encryption method to decrypter.
+//
+// Add a new method or replace existing one to change decryption.
+// Set it to nil to disable decryption of values.
+var Decrypters = map[string]Decrypter{
+	AESGCMMethod: AESGCM{},
+}
+
+// EncryptedValue is a parsed `ENC[METHOD,key:value,...]` envelope.
+//
+// Envelope looks like SOPS values but files encrypted with SOPS are not supported:
+// SOPS encrypts a file with its own data key and metadata, these values are encrypted directly with the key.
+type EncryptedValue struct {
+	// Method is an encryption method like AES256_GCM.
+	Method string
+	// Fields are key-value pairs in the envelope, like data, iv and tag.
+	Fields map[string]string
+}
+
+// ParseEncryptedValue parses `ENC[METHOD,key:value,...]` formatted string.
+//
+// Returns false if string is not an encrypted value.
+func ParseEncryptedValue(s string) (EncryptedValue, bool) {
+	if !strings.HasPrefix(s, encPrefix) || !strings.HasSuffix(s, encSuffix) {
+		return EncryptedValue{}, false
+	}
+
+	parts := strings.Split(s[len(encPrefix):len(s)-len(encSuffix)], ",")
+
+	v := EncryptedValue{
+		Method: strings.TrimSpace(parts[0]),
+		Fields: make(map[string]string, len(parts)-1),
+	}
+
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(p, ":", 2)
+		if len(kv) != 2 {
+			return EncryptedValue{}, false
+		}
+
+		v.Fields[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return v, true
+}
+
+// DecryptMap replaces encrypted string values in the map with decrypted values.
+//
+// Nested maps and slices are checked recursively.
+func DecryptMap(ctx context.Context, m map[string]interface{}) error {
+	if len(Decrypters) == 0 {
+		return nil
+	}
+
+	_, err := decryptValue(ctx, "", m)
+
+	return err
+}
+
+func decryptValue(ctx context.Context, path string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		encValue, ok := ParseEncryptedValue(v)
+		if !ok {
+			return v, nil
+		}
+
+		decrypter, ok := Decrypters[encValue.Method]
+		if !ok {
+			return nil, fmt.Errorf("%w for method %q in key %q", ErrNoDecrypter, encValue.Method, path)
+		}
+
+		value, err := decrypter.Decrypt(ctx, encValue)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key %q: %w", path, err)
+		}
+
+		return value, nil
+	case map[string]interface{}:
+		for k, inner := range v {
+			newValue, err := decryptValue(ctx, joinPath(path, k), inner)
+			if err != nil {
+				return nil, err
+			}
+
+			v[k] = newValue
+		}
+	case []interface{}:
+		for i, inner := range v {
+			newValue, err := decryptValue(ctx, fmt.Sprintf("%s[%d]", path, i), inner)
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = newValue
+		}
+	}
+
+	return v, nil
+}
+
+func joinPath(outer, inner string) string {
+	if outer == "" {
+		return inner
+	}
+
+	return outer + "." + inner
+}
+
+// AESGCMMethod is the method name of AESGCM encryption.
+const AESGCMMethod = "AES256_GCM"
+
+// AESGCM decrypts `ENC[AES256_GCM,data:...,iv:...,tag:...]` values, all fields are base64 encoded.
+//
+// Key is 32 bytes long, if not set it is read from EncryptionKeyEnv or EncryptionKeyFileEnv as base64.
+// Variables are read from the environment source in the context.
+type AESGCM struct {
+	Key []byte
+}
+
+// Decrypt decrypts the value with AES-GCM.
+func (a AESGCM) Decrypt(ctx context.Context, value EncryptedValue) (string, error) {
+	fields := make(map[string][]byte, 3)
+
+	for _, name := range []string{"data", "iv", "tag"} {
+		v, err := base64.StdEncoding.DecodeString(value.Fields[name])
+		if err != nil {
+			return "", fmt.Errorf("field %q: %w", name, err)
+		}
+
+		fields[name] = v
+	}
+
+	gcm, err := a.gcm(ctx, len(fields["iv"]))
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := gcm.Open(nil, fields["iv"], append(fields["data"], fields["tag"]...), nil)
+	if err != nil {
+		return "", fmt.Errorf("open: %w", err)
+	}
+
+	return string(plain), nil
+}
+
+// Encrypt encrypts the plain text and returns the `ENC[AES256_GCM,...]` value.
+func (a AESGCM) Encrypt(plain string) (string, error) {
+	const nonceSize = 32
+
+	gcm, err := a.gcm(context.Background(), nonceSize)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, nonceSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(plain), nil)
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return fmt.Sprintf("%s%s,data:%s,iv:%s,tag:%s%s",
+		encPrefix,
+		AESGCMMethod,
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		encSuffix,
+	), nil
+}
+
+func (a AESGCM) gcm(ctx context.Context, nonceSize int) (cipher.AEAD, error) {
+	key, err := a.key(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// aes.NewCipher also accepts AES-128 and AES-192 keys.
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%w, got %d", ErrInvalidKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCMWithNonceSize(block, nonceSize)
+}
+
+func (a AESGCM) key(ctx context.Context) ([]byte, error) {
+	if len(a.Key) != 0 {
+		return a.Key, nil
+	}
+
+	source := internal.EnvSourceFromContext(ctx)
+
+	keyBase64, ok := source.LookupEnv(EncryptionKeyEnv)
+	if !ok {
+		keyFile, ok := source.LookupEnv(EncryptionKeyFileEnv)
+		if !ok {
+			return nil, fmt.Errorf("encryption key not found, set %s or %s", EncryptionKeyEnv, EncryptionKeyFileEnv)
+		}
+
+		v, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
+		}
+
+		keyBase64 = string(v)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(keyBase64))
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+
+	return key, nil
+}
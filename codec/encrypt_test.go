@@ -0,0 +1,88 @@
+package codec
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+func TestAESGCM(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	enc, err := AESGCM{Key: key}.Encrypt("my-secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enc, "ENC[AES256_GCM,data:"))
+
+	t.Setenv(EncryptionKeyEnv, base64.StdEncoding.EncodeToString(key))
+
+	type config struct {
+		Password string `cfg:"password"`
+		Inner    struct {
+			Tokens []string `cfg:"tokens"`
+		} `cfg:"inner"`
+	}
+
+	input := `
+password: "` + enc + `"
+inner:
+  tokens:
+    - plain
+    - "` + enc + `"
+`
+
+	var c config
+	require.NoError(t, LoadReaderWithDecoder(strings.NewReader(input), &c, YAML{}, "cfg"))
+
+	assert.Equal(t, "my-secret", c.Password)
+	assert.Equal(t, []string{"plain", "my-secret"}, c.Inner.Tokens)
+}
+
+func TestDecryptMap_Error(t *testing.T) {
+	err := DecryptMap(context.Background(), map[string]interface{}{
+		"inner": map[string]interface{}{
+			"key": "ENC[UNKNOWN,data:abc]",
+		},
+	})
+
+	assert.True(t, errors.Is(err, ErrNoDecrypter))
+	assert.Contains(t, err.Error(), `"inner.key"`)
+
+	t.Setenv(EncryptionKeyEnv, base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+
+	err = DecryptMap(context.Background(), map[string]interface{}{
+		"key": "ENC[AES256_GCM,data:YWJj,iv:YWJjZGVmZ2hpamts,tag:YWJjZGVmZ2hpamtsbW5vcA==]",
+	})
+	assert.Error(t, err)
+}
+
+func TestAESGCM_Key(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	enc, err := AESGCM{Key: key}.Encrypt("my-secret")
+	require.NoError(t, err)
+
+	_, err = AESGCM{Key: key[:16]}.Encrypt("my-secret")
+	assert.True(t, errors.Is(err, ErrInvalidKeySize))
+
+	ctx := internal.WithEnvSource(context.Background(), internal.EnvMap{
+		EncryptionKeyEnv: base64.StdEncoding.EncodeToString(key),
+	})
+
+	m := map[string]interface{}{"password": enc}
+	require.NoError(t, DecryptMap(ctx, m))
+	assert.Equal(t, "my-secret", m["password"])
+
+	ctx = internal.WithEnvSource(context.Background(), internal.EnvMap{
+		EncryptionKeyEnv: base64.StdEncoding.EncodeToString(key[:24]),
+	})
+
+	err = DecryptMap(ctx, map[string]interface{}{"password": enc})
+	assert.True(t, errors.Is(err, ErrInvalidKeySize))
+}
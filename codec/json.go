@@ -1,6 +1,7 @@
 package codec
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 )
@@ -8,10 +9,26 @@ import (
 // JSON is a json decoder.
 type JSON struct {
 	Strict bool
+	// Comments enables to use comments and trailing commas in the input (JSONC).
+	Comments bool
 }
 
 // Decode is a decoder function for json.
 func (c JSON) Decode(r io.Reader, to interface{}) error {
+	if c.Comments {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		data, err = StandardizeJSON(data, false)
+		if err != nil {
+			return err
+		}
+
+		r = bytes.NewReader(data)
+	}
+
 	decoder := json.NewDecoder(r)
 
 	if c.Strict {
@@ -21,4 +38,9 @@ func (c JSON) Decode(r io.Reader, to interface{}) error {
 	return decoder.Decode(to)
 }
 
+// IsStrict returns true if strict option is enabled.
+func (c JSON) IsStrict() bool {
+	return c.Strict
+}
+
 var _ Decoder = JSON{}
@@ -0,0 +1,193 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// JSON5 is a decoder for JSON5 and JSONC(JSON with comments) inputs.
+//
+// Supported extensions to JSON are line and block comments, trailing commas,
+// unquoted keys and single quoted strings.
+// Other JSON5 features like hexadecimal numbers and Infinity are not supported.
+type JSON5 struct {
+	Strict bool
+}
+
+// Decode is a decoder function for json5.
+func (c JSON5) Decode(r io.Reader, to interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	data, err = StandardizeJSON(data, true)
+	if err != nil {
+		return err
+	}
+
+	return JSON{Strict: c.Strict}.Decode(bytes.NewReader(data), to)
+}
+
+// IsStrict returns true if strict option is enabled.
+func (c JSON5) IsStrict() bool {
+	return c.Strict
+}
+
+var _ Decoder = JSON5{}
+
+var errUnterminated = errors.New("json: unterminated comment or string")
+
+// StandardizeJSON converts the input to the standard JSON by removing comments and trailing commas.
+//
+// If json5 is true, unquoted keys are quoted and single quoted strings are converted to double quoted.
+// New lines in comments are kept, so line numbers are same with the input.
+func StandardizeJSON(data []byte, json5 bool) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+
+		switch {
+		case ch == '"' || (json5 && ch == '\''):
+			end, str, err := readJSONString(data, i)
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, str...)
+			i = end
+		case ch == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end, newLines, err := skipJSONComment(data, i)
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, newLines...)
+			i = end
+		case ch == ',':
+			next, err := nextJSONToken(data, i+1)
+			if err != nil {
+				return nil, err
+			}
+
+			if next < len(data) && (data[next] == '}' || data[next] == ']') {
+				continue
+			}
+
+			out = append(out, ch)
+		case json5 && isIdentStart(ch):
+			end := i
+			for end < len(data) && isIdentPart(data[end]) {
+				end++
+			}
+
+			next, err := nextJSONToken(data, end)
+			if err != nil {
+				return nil, err
+			}
+
+			if next < len(data) && data[next] == ':' {
+				out = append(out, '"')
+				out = append(out, data[i:end]...)
+				out = append(out, '"')
+			} else {
+				out = append(out, data[i:end]...)
+			}
+
+			i = end - 1
+		default:
+			out = append(out, ch)
+		}
+	}
+
+	return out, nil
+}
+
+// readJSONString reads the string starting at i and returns end index and double quoted string.
+func readJSONString(data []byte, i int) (int, []byte, error) {
+	quote := data[i]
+	str := []byte{'"'}
+
+	for j := i + 1; j < len(data); j++ {
+		ch := data[j]
+
+		switch {
+		case ch == '\\' && j+1 < len(data):
+			j++
+			// \' is not valid in JSON
+			if data[j] == '\'' {
+				str = append(str, '\'')
+
+				continue
+			}
+
+			str = append(str, ch, data[j])
+		case ch == quote:
+			return j, append(str, '"'), nil
+		case ch == '"':
+			// double quote in single quoted string
+			str = append(str, '\\', '"')
+		default:
+			str = append(str, ch)
+		}
+	}
+
+	return 0, nil, errUnterminated
+}
+
+// skipJSONComment skips the comment starting at i and returns end index and new lines in the comment.
+func skipJSONComment(data []byte, i int) (int, []byte, error) {
+	if data[i+1] == '/' {
+		end := bytes.IndexByte(data[i:], '\n')
+		if end == -1 {
+			return len(data) - 1, nil, nil
+		}
+
+		// keep new line character to output
+		return i + end - 1, nil, nil
+	}
+
+	end := bytes.Index(data[i+2:], []byte("*/"))
+	if end == -1 {
+		return 0, nil, errUnterminated
+	}
+
+	end += i + 2
+
+	return end + 1, bytes.Repeat([]byte("\n"), bytes.Count(data[i:end], []byte("\n"))), nil
+}
+
+// nextJSONToken returns the index of the next character that is not space or comment.
+func nextJSONToken(data []byte, i int) (int, error) {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		case '/':
+			if i+1 >= len(data) || (data[i+1] != '/' && data[i+1] != '*') {
+				return i, nil
+			}
+
+			end, _, err := skipJSONComment(data, i)
+			if err != nil {
+				return 0, err
+			}
+
+			i = end + 1
+		default:
+			return i, nil
+		}
+	}
+
+	return i, nil
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || ch == '$' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || (ch >= '0' && ch <= '9')
+}
@@ -0,0 +1,65 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardizeJSON(t *testing.T) {
+	input := `{
+	// line comment
+	unquoted: 'single "quoted" it\'s', /* block
+	comment */
+	"url": "http://example.com/*not comment*/",
+	list: [1, 2, 3,],
+	$inner: {key_1: true,},
+}`
+
+	want := `{
+	
+	"unquoted": "single \"quoted\" it's", 
+
+	"url": "http://example.com/*not comment*/",
+	"list": [1, 2, 3],
+	"$inner": {"key_1": true}
+}`
+
+	got, err := StandardizeJSON([]byte(input), true)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	_, err = StandardizeJSON([]byte(`{"a": 1 /* not closed`), false)
+	assert.Error(t, err)
+}
+
+func TestJSON5_Decode(t *testing.T) {
+	type config struct {
+		Name  string   `cfg:"name"`
+		Ports []int    `cfg:"ports"`
+		Tags  []string `cfg:"tags"`
+	}
+
+	var c config
+	require.NoError(t, LoadReaderWithDecoder(strings.NewReader(`{
+	// service name
+	name: 'test',
+	ports: [80, 443,],
+	tags: ["a", 'b'],
+}`), &c, JSON5{}, "cfg"))
+
+	assert.Equal(t, config{Name: "test", Ports: []int{80, 443}, Tags: []string{"a", "b"}}, c)
+
+	c = config{}
+	require.NoError(t, LoadReaderWithDecoder(strings.NewReader(`{
+	"name": "test", // why
+	"ports": [80,],
+}`), &c, JSON{Comments: true}, "cfg"))
+
+	assert.Equal(t, config{Name: "test", Ports: []int{80}}, c)
+
+	assert.Error(t, JSON{}.Decode(strings.NewReader(`{"name": "test", // why
+}`), &c))
+}
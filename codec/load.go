@@ -1,20 +1,59 @@
 package codec
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 )
 
 // LoadReaderWithDecoder will decode input in `r` into `to` by using `decoder`.
 func LoadReaderWithDecoder(r io.Reader, to interface{}, decoder Decoder, tag string) error {
-	mapping := map[string]interface{}{}
-	if err := decoder.Decode(r, &mapping); err != nil {
-		return fmt.Errorf("LoadReaderWithDecoder: decoder.Decode error: %w", err)
+	return LoadReaderWithSource(context.Background(), r, to, decoder, tag, "")
+}
+
+// LoadReaderWithSource is same as LoadReaderWithDecoder but reports unknown keys based on Strict mode.
+//
+// If decoder implements StrictDecoder and it is strict, StrictError mode is used.
+// Source is the name of the input like file name, Consul key or Vault path, used in reports.
+//
+// Decode errors are returned as *DecodeError with source and position information.
+func LoadReaderWithSource(ctx context.Context, r io.Reader, to interface{}, decoder Decoder, tag, source string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("LoadReaderWithDecoder: read error: %w", err)
 	}
 
-	if err := MapDecoder(&mapping, to, tag); err != nil {
+	mapping, err := DecodeMap(ctx, bytes.NewReader(data), decoder)
+	if err != nil {
+		return fmt.Errorf("LoadReaderWithDecoder: %w", NewDecodeError(source, data, decoder, err))
+	}
+
+	if err := MapDecoderStrict(ctx, &mapping, to, tag, source, DecoderStrictMode(decoder)); err != nil {
+		var unknownErr *UnknownKeysError
+		if !errors.As(err, &unknownErr) {
+			err = NewDecodeError(source, data, decoder, err)
+		}
+
 		return fmt.Errorf("LoadReaderWithDecoder codec.MapDecoder error: %w", err)
 	}
 
 	return nil
 }
+
+// DecodeMap decodes input in `r` to a generic map by using `decoder`.
+//
+// Encrypted values are decrypted with Decrypters, see DecryptMap.
+func DecodeMap(ctx context.Context, r io.Reader, decoder Decoder) (map[string]interface{}, error) {
+	mapping := map[string]interface{}{}
+	if err := decoder.Decode(r, &mapping); err != nil {
+		return nil, fmt.Errorf("decoder.Decode error: %w", err)
+	}
+
+	if err := DecryptMap(ctx, mapping); err != nil {
+		return nil, fmt.Errorf("DecryptMap error: %w", err)
+	}
+
+	return mapping, nil
+}
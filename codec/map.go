@@ -1,9 +1,11 @@
 package codec
 
 import (
+	"context"
 	"reflect"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/worldline-go/struct2"
 	"github.com/xhit/go-str2duration/v2"
 )
@@ -46,6 +48,19 @@ var (
 // it exposes functionality to convert an arbitrary map[string]interface{}
 // into a native Go structure with given tag name.
 func MapDecoder(input, output interface{}, tag string) error {
+	return MapDecoderWithSource(context.Background(), input, output, tag, "")
+}
+
+// MapDecoderWithSource is same as MapDecoder but reports unknown keys based on Strict mode.
+//
+// Source is the name of the input like file name, Consul key or Vault path, used in reports.
+// Context is used for logging the warnings.
+func MapDecoderWithSource(ctx context.Context, input, output interface{}, tag, source string) error {
+	return MapDecoderStrict(ctx, input, output, tag, source, Strict)
+}
+
+// MapDecoderStrict is same as MapDecoderWithSource with given strict mode instead of Strict.
+func MapDecoderStrict(ctx context.Context, input, output interface{}, tag, source string, strict StrictMode) error {
 	decoder := struct2.Decoder{
 		TagName:               tag,
 		BackupTagName:         BackupTagName,
@@ -55,5 +70,117 @@ func MapDecoder(input, output interface{}, tag string) error {
 		WeaklyDashUnderscore:  WeaklyDashUnderscore,
 	}
 
-	return decoder.Decode(input, output)
+	if err := decoder.Decode(input, output); err != nil {
+		return err
+	}
+
+	if inputValue := reflect.Indirect(reflect.ValueOf(input)); inputValue.IsValid() {
+		clearNulls(inputValue.Interface(), reflect.ValueOf(output), tag)
+	}
+
+	if strict == StrictOff {
+		return nil
+	}
+
+	keys := UnknownKeys(input, output, tag)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if strict == StrictError {
+		return &UnknownKeysError{Source: source, Keys: keys}
+	}
+
+	log.Ctx(ctx).Warn().Str("source", source).Strs("keys", keys).Msg("unknown config keys")
+
+	return nil
+}
+
+// clearNulls sets the fields with null values in data to zero value, pointers become nil and map keys are deleted.
+//
+// Decoder skips null values, so this is the way to unset a value set by an earlier loader.
+func clearNulls(data interface{}, val reflect.Value, tag string) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		fields, remain := structFields(val.Type(), tag)
+		if remain {
+			return
+		}
+
+		for k, v := range m {
+			field, ok := matchField(fields, k)
+			if !ok {
+				continue
+			}
+
+			fieldValue, err := val.FieldByIndexErr(field.index)
+			if err != nil {
+				continue
+			}
+
+			if v == nil {
+				if fieldValue.CanSet() {
+					fieldValue.Set(reflect.Zero(fieldValue.Type()))
+				}
+
+				continue
+			}
+
+			clearNulls(v, fieldValue, tag)
+		}
+	case reflect.Map:
+		m, ok := data.(map[string]interface{})
+		if !ok || val.IsNil() || val.Type().Key().Kind() != reflect.String {
+			return
+		}
+
+		for k, v := range m {
+			key := reflect.ValueOf(k).Convert(val.Type().Key())
+
+			if v == nil {
+				val.SetMapIndex(key, reflect.Value{})
+
+				continue
+			}
+
+			if elem := val.MapIndex(key); elem.IsValid() {
+				clearNulls(v, elem, tag)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		s, ok := data.([]interface{})
+		if !ok {
+			return
+		}
+
+		for i, v := range s {
+			if i >= val.Len() {
+				return
+			}
+
+			elem := val.Index(i)
+			if v == nil {
+				if elem.CanSet() {
+					elem.Set(reflect.Zero(elem.Type()))
+				}
+
+				continue
+			}
+
+			clearNulls(v, elem, tag)
+		}
+	}
 }
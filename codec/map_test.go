@@ -0,0 +1,49 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapDecoder_Null(t *testing.T) {
+	type inner struct {
+		Host string `cfg:"host"`
+		Port int    `cfg:"port"`
+	}
+
+	type config struct {
+		Debug  *bool             `cfg:"debug"`
+		Name   string            `cfg:"name"`
+		Inner  inner             `cfg:"inner"`
+		Server *inner            `cfg:"server"`
+		Labels map[string]string `cfg:"labels"`
+		Tags   []string          `cfg:"tags"`
+	}
+
+	debug := true
+
+	c := config{
+		Debug:  &debug,
+		Name:   "app",
+		Inner:  inner{Host: "localhost", Port: 8080},
+		Server: &inner{Host: "server"},
+		Labels: map[string]string{"team": "core", "env": "dev"},
+		Tags:   []string{"a"},
+	}
+
+	require.NoError(t, MapDecoder(map[string]interface{}{
+		"debug":  nil,
+		"inner":  map[string]interface{}{"port": nil},
+		"server": nil,
+		"labels": map[string]interface{}{"env": nil},
+		"tags":   nil,
+	}, &c, "cfg"))
+
+	assert.Equal(t, config{
+		Name:   "app",
+		Inner:  inner{Host: "localhost"},
+		Labels: map[string]string{"team": "core"},
+	}, c)
+}
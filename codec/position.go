@@ -0,0 +1,470 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// SnippetLines is the number of lines shown before the error line in DecodeError.
+var SnippetLines = 2
+
+var (
+	reYAMLErrorLine = regexp.MustCompile(`line (\d+)`)
+	reDecodePath    = regexp.MustCompile(`'([^']+)'`)
+	reIndex         = regexp.MustCompile(`\[\d+\]`)
+)
+
+// Position is a place in the input, line and column start from 1.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Positioner is implemented by decoders that can find positions in the raw input.
+type Positioner interface {
+	// KeyPositions returns positions of keys with dotted paths, list items have [index] suffix.
+	KeyPositions(data []byte) map[string]Position
+	// ErrorPosition returns position of the error returned from Decode.
+	ErrorPosition(data []byte, err error) (Position, bool)
+}
+
+// DecodeError is an error with the source name, position and key path information.
+type DecodeError struct {
+	// Source is the name of the input like file name or Consul key.
+	Source string
+	// Path is the dotted key path, empty if not known.
+	Path string
+	// Position of the error, zero if not known.
+	Position Position
+	// Snippet is the part of the input around the error line.
+	Snippet string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+
+	b.WriteString(e.Source)
+
+	if e.Position.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", e.Position.Line, e.Position.Column)
+	}
+
+	if e.Path != "" {
+		if b.Len() > 0 {
+			b.WriteString(": ")
+		}
+
+		fmt.Fprintf(&b, "key %q", e.Path)
+	}
+
+	if b.Len() > 0 {
+		b.WriteString(": ")
+	}
+
+	b.WriteString(e.Err.Error())
+
+	if e.Snippet != "" {
+		b.WriteString("\n")
+		b.WriteString(e.Snippet)
+	}
+
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// NewDecodeError wraps err with source and position information.
+//
+// Position found with decoder if it implements Positioner,
+// first as a syntax error and after that as a key path in MapDecoder error.
+func NewDecodeError(source string, data []byte, decoder Decoder, err error) *DecodeError {
+	decodeErr := &DecodeError{
+		Source: source,
+		Err:    err,
+	}
+
+	positioner, _ := decoder.(Positioner)
+
+	if positioner != nil {
+		if pos, ok := positioner.ErrorPosition(data, err); ok {
+			decodeErr.Position = pos
+			decodeErr.Snippet = Snippet(data, pos)
+
+			return decodeErr
+		}
+	}
+
+	if m := reDecodePath.FindStringSubmatch(err.Error()); m != nil {
+		decodeErr.Path = m[1]
+	}
+
+	if positioner != nil && decodeErr.Path != "" {
+		if pos, ok := findKeyPosition(positioner.KeyPositions(data), decodeErr.Path); ok {
+			decodeErr.Position = pos
+			decodeErr.Snippet = Snippet(data, pos)
+		}
+	}
+
+	return decodeErr
+}
+
+// Snippet returns lines before and at the position with line numbers and a marker under the column.
+func Snippet(data []byte, pos Position) string {
+	if pos.Line <= 0 {
+		return ""
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if pos.Line > len(lines) {
+		return ""
+	}
+
+	start := pos.Line - SnippetLines
+	if start < 1 {
+		start = 1
+	}
+
+	width := len(strconv.Itoa(pos.Line))
+
+	var b strings.Builder
+	for i := start; i <= pos.Line; i++ {
+		fmt.Fprintf(&b, "%*d | %s\n", width, i, strings.TrimRight(lines[i-1], "\r"))
+	}
+
+	if pos.Column > 0 {
+		fmt.Fprintf(&b, "%*s | %s^", width, "", strings.Repeat(" ", pos.Column-1))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// offsetPosition converts byte offset to the position.
+func offsetPosition(data []byte, offset int64) Position {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+
+	return Position{Line: line, Column: column}
+}
+
+// findKeyPosition finds path in positions with same matching rules of MapDecoder.
+func findKeyPosition(positions map[string]Position, path string) (Position, bool) {
+	if pos, ok := positions[path]; ok {
+		return pos, true
+	}
+
+	want := splitPath(path)
+
+	for k, pos := range positions {
+		got := splitPath(k)
+		if len(got) != len(want) {
+			continue
+		}
+
+		match := true
+
+		for i := range got {
+			if !strings.EqualFold(normalizeKey(got[i]), normalizeKey(want[i])) {
+				match = false
+
+				break
+			}
+		}
+
+		if match {
+			return pos, true
+		}
+	}
+
+	return Position{}, false
+}
+
+func splitPath(path string) []string {
+	return strings.Split(reIndex.ReplaceAllStringFunc(path, func(s string) string { return "." + s }), ".")
+}
+
+// KeyPositions returns positions of keys in YAML document.
+func (c YAML) KeyPositions(data []byte) map[string]Position {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil
+	}
+
+	positions := map[string]Position{}
+	yamlPositions(positions, "", &node)
+
+	return positions
+}
+
+func yamlPositions(positions map[string]Position, path string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			yamlPositions(positions, path, n)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(path, key.Value)
+
+			positions[keyPath] = Position{Line: key.Line, Column: key.Column}
+			yamlPositions(positions, keyPath, value)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+			positions[itemPath] = Position{Line: n.Line, Column: n.Column}
+			yamlPositions(positions, itemPath, n)
+		}
+	}
+}
+
+// ErrorPosition returns the line of the YAML error.
+func (c YAML) ErrorPosition(_ []byte, err error) (Position, bool) {
+	m := reYAMLErrorLine.FindStringSubmatch(err.Error())
+	if m == nil || !strings.Contains(err.Error(), "yaml:") {
+		return Position{}, false
+	}
+
+	line, _ := strconv.Atoi(m[1])
+
+	return Position{Line: line}, true
+}
+
+// KeyPositions returns positions of keys in JSON document.
+//
+// If Comments option is enabled, columns might be shifted in lines with comments.
+func (c JSON) KeyPositions(data []byte) map[string]Position {
+	if c.Comments {
+		data, _ = StandardizeJSON(data, false)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	positions := map[string]Position{}
+
+	if err := jsonPositions(decoder, data, positions, ""); err != nil {
+		return nil
+	}
+
+	return positions
+}
+
+func jsonPositions(decoder *json.Decoder, data []byte, positions map[string]Position, path string) error {
+	offset := decoder.InputOffset()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		for decoder.More() {
+			keyOffset := decoder.InputOffset()
+
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+
+			keyPath := joinPath(path, fmt.Sprint(key))
+			positions[keyPath] = offsetPosition(data, skipSpace(data, keyOffset))
+
+			if err := jsonPositions(decoder, data, positions, keyPath); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; decoder.More(); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			positions[itemPath] = offsetPosition(data, skipSpace(data, decoder.InputOffset()))
+
+			if err := jsonPositions(decoder, data, positions, itemPath); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unexpected delimiter %v at %d", delim, offset)
+	}
+
+	// closing delimiter
+	_, err = decoder.Token()
+
+	return err
+}
+
+// skipSpace returns offset of the next token, skipping separators.
+func skipSpace(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+
+	return offset
+}
+
+// ErrorPosition returns the position of the JSON syntax or type error.
+//
+// JSON errors have offset after reading the wrong byte, position points to the last read byte.
+func (c JSON) ErrorPosition(data []byte, err error) (Position, bool) {
+	if c.Comments {
+		data, _ = StandardizeJSON(data, false)
+	}
+
+	var offset int64
+
+	var syntaxErr *json.SyntaxError
+
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return Position{}, false
+	}
+
+	if offset > 0 {
+		offset--
+	}
+
+	return offsetPosition(data, offset), true
+}
+
+// KeyPositions returns positions of keys in TOML document.
+//
+// Positions are taken from the nodes of the TOML parser, so dotted, quoted and inline table keys are found.
+// Array of tables have [index] suffix, array items are added only if they have a position.
+func (c TOML) KeyPositions(data []byte) map[string]Position {
+	positions := map[string]Position{}
+	arrayIndex := map[string]int{}
+
+	var table string
+
+	var parser unstable.Parser
+	parser.Reset(data)
+
+	for parser.NextExpression() {
+		expr := parser.Expression()
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			table = ""
+
+			for it := expr.Key(); it.Next(); {
+				table = joinPath(table, string(it.Node().Data))
+
+				if it.IsLast() && expr.Kind == unstable.ArrayTable {
+					name := table
+					table = fmt.Sprintf("%s[%d]", name, arrayIndex[name])
+					arrayIndex[name]++
+				} else if n, ok := arrayIndex[table]; ok {
+					// table of the last item in the array of tables
+					table = fmt.Sprintf("%s[%d]", table, n-1)
+				}
+
+				setTOMLPosition(&parser, positions, table, it.Node())
+			}
+		case unstable.KeyValue:
+			tomlKeyValuePositions(&parser, positions, table, expr)
+		}
+	}
+
+	return positions
+}
+
+func tomlKeyValuePositions(parser *unstable.Parser, positions map[string]Position, path string, node *unstable.Node) {
+	for it := node.Key(); it.Next(); {
+		path = joinPath(path, string(it.Node().Data))
+		setTOMLPosition(parser, positions, path, it.Node())
+	}
+
+	tomlValuePositions(parser, positions, path, node.Value())
+}
+
+func tomlValuePositions(parser *unstable.Parser, positions map[string]Position, path string, node *unstable.Node) {
+	switch node.Kind {
+	case unstable.InlineTable:
+		for it := node.Children(); it.Next(); {
+			tomlKeyValuePositions(parser, positions, path, it.Node())
+		}
+	case unstable.Array:
+		i := 0
+
+		for it := node.Children(); it.Next(); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+			setTOMLPosition(parser, positions, itemPath, it.Node())
+			tomlValuePositions(parser, positions, itemPath, it.Node())
+		}
+	}
+}
+
+// setTOMLPosition sets the position of the node if it has one and path is not set before.
+func setTOMLPosition(parser *unstable.Parser, positions map[string]Position, path string, node *unstable.Node) {
+	if _, ok := positions[path]; ok || node.Raw.Length == 0 {
+		return
+	}
+
+	start := parser.Shape(node.Raw).Start
+	positions[path] = Position{Line: start.Line, Column: start.Column}
+}
+
+// ErrorPosition returns the position of the TOML parse error.
+func (c TOML) ErrorPosition(_ []byte, err error) (Position, bool) {
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		return Position{Line: parseErr.Position.Line, Column: parseErr.Position.Col}, true
+	}
+
+	return Position{}, false
+}
+
+// KeyPositions returns positions of keys in JSON5 document, columns might be shifted.
+func (c JSON5) KeyPositions(data []byte) map[string]Position {
+	data, _ = StandardizeJSON(data, true)
+
+	return JSON{}.KeyPositions(data)
+}
+
+// ErrorPosition returns the position of the JSON5 error, column might be shifted.
+func (c JSON5) ErrorPosition(data []byte, err error) (Position, bool) {
+	data, _ = StandardizeJSON(data, true)
+
+	return JSON{}.ErrorPosition(data, err)
+}
+
+var (
+	_ Positioner = JSON5{}
+	_ Positioner = YAML{}
+	_ Positioner = JSON{}
+	_ Positioner = TOML{}
+)
@@ -0,0 +1,152 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type positionConfig struct {
+	Name string `cfg:"name"`
+	DB   struct {
+		Host string `cfg:"host"`
+		Port int    `cfg:"port"`
+	} `cfg:"db"`
+}
+
+func TestDecodeError_Position(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		decoder  Decoder
+		path     string
+		position Position
+		snippet  string
+	}{
+		{
+			name:     "yaml syntax",
+			input:    "name: test\ndb:\n  host: x\n port: 1",
+			decoder:  YAML{},
+			position: Position{Line: 3},
+		},
+		{
+			name:     "yaml conversion",
+			input:    "name: test\ndb:\n  host: x\n  port: abc",
+			decoder:  YAML{},
+			path:     "db.port",
+			position: Position{Line: 4, Column: 3},
+			snippet:  "2 | db:\n3 |   host: x\n4 |   port: abc\n  |   ^",
+		},
+		{
+			name:     "json syntax",
+			input:    "{\n  \"name\": \"test\",\n  \"db\": {\"port\": 1,}\n}",
+			decoder:  JSON{},
+			position: Position{Line: 3, Column: 20},
+		},
+		{
+			name:     "json conversion",
+			input:    "{\n  \"name\": \"test\",\n  \"db\": {\n    \"port\": \"abc\"\n  }\n}",
+			decoder:  JSON{},
+			path:     "db.port",
+			position: Position{Line: 4, Column: 5},
+		},
+		{
+			name:     "toml syntax",
+			input:    "name = \"test\"\n[db]\nport = = 1",
+			decoder:  TOML{},
+			position: Position{Line: 3, Column: 8},
+		},
+		{
+			name:     "toml conversion",
+			input:    "name = \"test\"\n[db]\nhost = \"x\"\nport = \"abc\"",
+			decoder:  TOML{},
+			path:     "db.port",
+			position: Position{Line: 4, Column: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c positionConfig
+
+			err := LoadReaderWithSource(context.Background(), strings.NewReader(tt.input), &c, tt.decoder, "cfg", "app.conf")
+
+			var decodeErr *DecodeError
+			require.True(t, errors.As(err, &decodeErr), err)
+
+			assert.Equal(t, "app.conf", decodeErr.Source)
+			assert.Equal(t, tt.path, decodeErr.Path)
+			assert.Equal(t, tt.position, decodeErr.Position)
+
+			if tt.snippet != "" {
+				assert.Equal(t, tt.snippet, decodeErr.Snippet)
+			}
+		})
+	}
+}
+
+func TestDecodeError_Error(t *testing.T) {
+	err := &DecodeError{
+		Source:   "app.yaml",
+		Path:     "db.port",
+		Position: Position{Line: 2, Column: 3},
+		Snippet:  "2 |   port: abc\n  |   ^",
+		Err:      errors.New("invalid"),
+	}
+
+	assert.Equal(t, "app.yaml:2:3: key \"db.port\": invalid\n2 |   port: abc\n  |   ^", err.Error())
+}
+
+func TestTOML_KeyPositions(t *testing.T) {
+	input := `name = "test"
+desc = """
+port = 1
+"""
+db.host = "x"
+"log.level" = "debug"
+limits = { rps = 100, burst = { max = 20 } }
+ports = [ 80, 443 ]
+
+[server]
+  tls.ca = "ca"
+
+[[servers]]
+host = "a"
+
+[[servers]]
+host = "b"
+
+[servers.tls]
+cert = "c"
+`
+
+	positions := TOML{}.KeyPositions([]byte(input))
+
+	for path, want := range map[string]Position{
+		"name":                {Line: 1, Column: 1},
+		"desc":                {Line: 2, Column: 1},
+		"db":                  {Line: 5, Column: 1},
+		"db.host":             {Line: 5, Column: 4},
+		"log.level":           {Line: 6, Column: 1},
+		"limits.rps":          {Line: 7, Column: 12},
+		"limits.burst.max":    {Line: 7, Column: 33},
+		"ports[1]":            {Line: 8, Column: 15},
+		"server":              {Line: 10, Column: 2},
+		"server.tls.ca":       {Line: 11, Column: 7},
+		"servers[0]":          {Line: 13, Column: 3},
+		"servers[0].host":     {Line: 14, Column: 1},
+		"servers[1].host":     {Line: 17, Column: 1},
+		"servers[1].tls":      {Line: 19, Column: 10},
+		"servers[1].tls.cert": {Line: 20, Column: 1},
+	} {
+		assert.Equal(t, want, positions[path], path)
+	}
+
+	// keys in multi-line strings are not keys
+	_, ok := positions["port"]
+	assert.False(t, ok)
+}
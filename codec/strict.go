@@ -0,0 +1,223 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StrictMode sets how keys that not mapped to any struct field are reported.
+type StrictMode int
+
+const (
+	// StrictOff ignores unknown keys.
+	StrictOff StrictMode = iota
+	// StrictWarn logs unknown keys as a warning with the logger in the context.
+	StrictWarn
+	// StrictError returns *UnknownKeysError if there is an unknown key.
+	StrictError
+)
+
+// Strict is the strict mode used when decoding maps to structs.
+//
+// Decoders with Strict option enabled (JSON, YAML, TOML) always use StrictError in LoadReaderWithDecoder.
+var Strict = StrictOff
+
+var reIgnoreSeperator = regexp.MustCompile(`[-_ ]`)
+
+// StrictDecoder is implemented by decoders that have a strict option.
+type StrictDecoder interface {
+	IsStrict() bool
+}
+
+// DecoderStrictMode returns StrictError if decoder implements StrictDecoder and it is strict, otherwise Strict.
+func DecoderStrictMode(decoder Decoder) StrictMode {
+	if d, ok := decoder.(StrictDecoder); ok && d.IsStrict() {
+		return StrictError
+	}
+
+	return Strict
+}
+
+// UnknownKeysError is returned in StrictError mode.
+type UnknownKeysError struct {
+	// Source is the name of the input like file name, Consul key or Vault path.
+	Source string
+	// Keys are full dotted paths of unknown keys.
+	Keys []string
+}
+
+func (e *UnknownKeysError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("unknown keys: %s", strings.Join(e.Keys, ", "))
+	}
+
+	return fmt.Sprintf("unknown keys in %s: %s", e.Source, strings.Join(e.Keys, ", "))
+}
+
+// UnknownKeys returns sorted dotted paths of keys in input map that do not map to any field in output.
+//
+// Matching rules are the same as in MapDecoder.
+func UnknownKeys(input, output interface{}, tag string) []string {
+	inputValue := reflect.Indirect(reflect.ValueOf(input))
+	if !inputValue.IsValid() {
+		return nil
+	}
+
+	var keys []string
+	unknownKeys(&keys, "", inputValue.Interface(), reflect.TypeOf(output), tag)
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func unknownKeys(keys *[]string, path string, data interface{}, typ reflect.Type, tag string) {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == nil {
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		fields, remain := structFields(typ, tag)
+		if remain {
+			return
+		}
+
+		for k, v := range m {
+			field, ok := matchField(fields, k)
+			if !ok {
+				*keys = append(*keys, joinPath(path, k))
+
+				continue
+			}
+
+			unknownKeys(keys, joinPath(path, k), v, field.Type, tag)
+		}
+	case reflect.Map:
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		for k, v := range m {
+			unknownKeys(keys, joinPath(path, k), v, typ.Elem(), tag)
+		}
+	case reflect.Slice, reflect.Array:
+		s, ok := data.([]interface{})
+		if !ok {
+			return
+		}
+
+		for i, v := range s {
+			unknownKeys(keys, fmt.Sprintf("%s[%d]", path, i), v, typ.Elem(), tag)
+		}
+	}
+}
+
+type namedField struct {
+	name string
+	// index is the index sequence of the field, squashed fields have the index of their parent first.
+	index []int
+	reflect.StructField
+}
+
+// structFields returns fields with their map names, second value is true if struct has a remain field.
+func structFields(typ reflect.Type, tag string) ([]namedField, bool) {
+	var fields []namedField
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		// Unexported fields can't be set, so their keys are not used.
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tagValue := field.Tag.Get(tag)
+		if tagValue == "" && BackupTagName != "" {
+			tagValue = field.Tag.Get(BackupTagName)
+		}
+
+		tagParts := strings.Split(tagValue, ",")
+		if hasOption(tagParts[1:], "remain") {
+			return nil, true
+		}
+
+		if hasOption(tagParts[1:], "squash") {
+			innerType := field.Type
+			if innerType.Kind() == reflect.Ptr {
+				innerType = innerType.Elem()
+			}
+
+			inner, remain := structFields(innerType, tag)
+			if remain {
+				return nil, true
+			}
+
+			for _, f := range inner {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+
+			continue
+		}
+
+		name := field.Name
+		if tagParts[0] != "" {
+			name = tagParts[0]
+		}
+
+		fields = append(fields, namedField{name: name, index: []int{i}, StructField: field})
+	}
+
+	return fields, false
+}
+
+func hasOption(opts []string, opt string) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchField(fields []namedField, key string) (namedField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(normalizeKey(f.name), normalizeKey(key)) {
+			return f, true
+		}
+	}
+
+	return namedField{}, false
+}
+
+func normalizeKey(k string) string {
+	if WeaklyIgnoreSeperator {
+		return reIgnoreSeperator.ReplaceAllString(k, "")
+	}
+
+	if WeaklyDashUnderscore {
+		return strings.ReplaceAll(k, "-", "_")
+	}
+
+	return k
+}
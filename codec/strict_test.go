@@ -0,0 +1,93 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type strictInner struct {
+	Host string `cfg:"host"`
+}
+
+type strictConfig struct {
+	Name     string                 `cfg:"name"`
+	LogLevel string                 `cfg:"log_level"`
+	Inner    strictInner            `cfg:"inner"`
+	Servers  []strictInner          `cfg:"servers"`
+	Extra    map[string]strictInner `cfg:"extra"`
+	Any      map[string]interface{} `cfg:"any"`
+}
+
+func TestUnknownKeys(t *testing.T) {
+	input := map[string]interface{}{
+		"name":     "test",
+		"logLevel": "debug",
+		"inner": map[string]interface{}{
+			"host": "localhost",
+			"port": 8080,
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a"},
+			map[string]interface{}{"hots": "b"},
+		},
+		"extra": map[string]interface{}{
+			"one": map[string]interface{}{"host": "a", "typo": "x"},
+		},
+		"any": map[string]interface{}{
+			"free": map[string]interface{}{"form": 1},
+		},
+		"nmae": "typo",
+	}
+
+	assert.Equal(t, []string{
+		"extra.one.typo",
+		"inner.port",
+		"nmae",
+		"servers[1].hots",
+	}, UnknownKeys(&input, &strictConfig{}, "cfg"))
+}
+
+func TestLoadReaderWithSource_Strict(t *testing.T) {
+	input := `{"name": "test", "inner": {"hots": "x"}}`
+
+	var c strictConfig
+
+	// strict decoder
+	err := LoadReaderWithSource(context.Background(), strings.NewReader(input), &c, JSON{Strict: true}, "cfg", "app.json")
+
+	var unknownErr *UnknownKeysError
+	require.True(t, errors.As(err, &unknownErr), err)
+	assert.Equal(t, "app.json", unknownErr.Source)
+	assert.Equal(t, []string{"inner.hots"}, unknownErr.Keys)
+
+	// not strict
+	require.NoError(t, LoadReaderWithSource(context.Background(), strings.NewReader(input), &c, JSON{}, "cfg", "app.json"))
+	assert.Equal(t, "test", c.Name)
+
+	// warning
+	defer func(v StrictMode) { Strict = v }(Strict)
+	Strict = StrictWarn
+
+	var buf bytes.Buffer
+	ctx := zerolog.New(&buf).WithContext(context.Background())
+
+	require.NoError(t, LoadReaderWithSource(ctx, strings.NewReader(input), &c, YAML{}, "cfg", "consul/app"))
+	assert.Contains(t, buf.String(), `"source":"consul/app"`)
+	assert.Contains(t, buf.String(), `"keys":["inner.hots"]`)
+}
+
+func TestTOML_Strict(t *testing.T) {
+	var c struct {
+		Name string `toml:"name"`
+	}
+
+	assert.Error(t, TOML{Strict: true}.Decode(strings.NewReader("name = 'x'\nother = 1"), &c))
+	assert.NoError(t, TOML{}.Decode(strings.NewReader("name = 'x'\nother = 1"), &c))
+}
@@ -1,21 +1,38 @@
 package codec
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/BurntSushi/toml"
 )
 
 // TOML is a toml decoder.
-type TOML struct{}
+type TOML struct {
+	Strict bool
+}
 
-// Decode is a decoder function for yaml.
+// Decode is a decoder function for toml.
 func (c TOML) Decode(r io.Reader, to interface{}) error {
 	decoder := toml.NewDecoder(r)
 
-	_, err := decoder.Decode(to)
+	md, err := decoder.Decode(to)
+	if err != nil {
+		return err
+	}
+
+	if c.Strict {
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("toml: unknown keys %v", undecoded)
+		}
+	}
+
+	return nil
+}
 
-	return err
+// IsStrict returns true if strict option is enabled.
+func (c TOML) IsStrict() bool {
+	return c.Strict
 }
 
-var _ Decoder = YAML{}
+var _ Decoder = TOML{}
@@ -6,9 +6,17 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// YAMLTagFunc resolves a node with a custom tag, like `!include`.
+//
+// Function can modify node in place, result of the node will be decoded.
+type YAMLTagFunc func(node *yaml.Node) error
+
 // YAML is a yaml decoder.
 type YAML struct {
 	Strict bool
+	// Tags is a map of custom tag names (with `!` prefix) to resolver functions.
+	// If it is empty, document decoded directly without checking tags.
+	Tags map[string]YAMLTagFunc
 }
 
 // Decode is a decoder function for yaml.
@@ -19,7 +27,40 @@ func (c YAML) Decode(r io.Reader, to interface{}) error {
 		decoder.KnownFields(true)
 	}
 
-	return decoder.Decode(to)
+	if len(c.Tags) == 0 {
+		return decoder.Decode(to)
+	}
+
+	var node yaml.Node
+	if err := decoder.Decode(&node); err != nil {
+		return err
+	}
+
+	if err := c.resolveTags(&node); err != nil {
+		return err
+	}
+
+	return node.Decode(to)
+}
+
+// resolveTags walks on the node tree and calls tag functions.
+func (c YAML) resolveTags(node *yaml.Node) error {
+	if fn, ok := c.Tags[node.Tag]; ok {
+		return fn(node)
+	}
+
+	for _, n := range node.Content {
+		if err := c.resolveTags(n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsStrict returns true if strict option is enabled.
+func (c YAML) IsStrict() bool {
+	return c.Strict
 }
 
 var _ Decoder = YAML{}
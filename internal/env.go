@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// EnvSource is a source of environment variables.
+type EnvSource interface {
+	// LookupEnv returns the value of the variable and true if it is set.
+	LookupEnv(key string) (string, bool)
+	// Environ returns all variables in "key=value" form.
+	Environ() []string
+}
+
+// OSEnv is the environment of the process.
+type OSEnv struct{}
+
+//nolint:golint
+func (OSEnv) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+//nolint:golint
+func (OSEnv) Environ() []string {
+	return os.Environ()
+}
+
+// EnvMap is a static map of variables.
+type EnvMap map[string]string
+
+//nolint:golint
+func (m EnvMap) LookupEnv(key string) (string, bool) {
+	val, ok := m[key]
+
+	return val, ok
+}
+
+//nolint:golint
+func (m EnvMap) Environ() []string {
+	environ := make([]string, 0, len(m))
+	for k, v := range m {
+		environ = append(environ, k+"="+v)
+	}
+
+	return environ
+}
+
+// EnvFunc is a lookup function as a source.
+//
+// Environ returns nil, so variables cannot be discovered with scanning.
+type EnvFunc func(key string) (string, bool)
+
+//nolint:golint
+func (f EnvFunc) LookupEnv(key string) (string, bool) {
+	return f(key)
+}
+
+//nolint:golint
+func (f EnvFunc) Environ() []string {
+	return nil
+}
+
+// EnvSnapshot returns a copy of the current environment of the process.
+func EnvSnapshot() EnvMap {
+	m := make(EnvMap)
+
+	for _, env := range os.Environ() {
+		if i := strings.Index(env, "="); i != -1 {
+			m[env[:i]] = env[i+1:]
+		}
+	}
+
+	return m
+}
+
+type envSourceKey struct{}
+
+// WithEnvSource returns a context holding the source.
+func WithEnvSource(ctx context.Context, source EnvSource) context.Context {
+	return context.WithValue(ctx, envSourceKey{}, source)
+}
+
+// EnvSourceFromContext returns the source in the context or OSEnv.
+func EnvSourceFromContext(ctx context.Context) EnvSource {
+	if ctx != nil {
+		if source, ok := ctx.Value(envSourceKey{}).(EnvSource); ok && source != nil {
+			return source
+		}
+	}
+
+	return OSEnv{}
+}
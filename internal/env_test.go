@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvSource(t *testing.T) {
+	t.Setenv("TEST_ENV_SOURCE", "process")
+
+	snapshot := EnvSnapshot()
+	assert.Equal(t, "process", snapshot["TEST_ENV_SOURCE"])
+
+	source := EnvMap{"TEST_ENV_SOURCE": "map"}
+	assert.Equal(t, "map", GetEnvWithFallback(source, "TEST_ENV_SOURCE", "fallback"))
+	assert.Equal(t, "fallback", GetEnvWithFallback(source, "NOT_EXIST", "fallback"))
+	assert.Equal(t, []string{"TEST_ENV_SOURCE=map"}, source.Environ())
+
+	fn := EnvFunc(func(key string) (string, bool) { return key, true })
+	assert.Equal(t, "KEY", GetEnvWithFallback(fn, "KEY", "fallback"))
+
+	ctx := WithEnvSource(context.Background(), source)
+	assert.Equal(t, source, EnvSourceFromContext(ctx))
+	assert.Equal(t, OSEnv{}, EnvSourceFromContext(context.Background()))
+}
@@ -2,7 +2,6 @@ package internal
 
 import (
 	"errors"
-	"os"
 	"reflect"
 	"strings"
 )
@@ -13,6 +12,9 @@ const DefaultConfigTag = "cfg"
 //nolint:golint
 const SkipFieldTagValue = "-"
 
+// DeprecatedMarker marks a name in the tag as deprecated, like `env:"DB_URL,DATABASE_URL!deprecated"`.
+const DeprecatedMarker = "!deprecated"
+
 //nolint:golint
 var ErrInputIsNotPointerOrStruct = errors.New("input value is not struct or not pointer")
 
@@ -27,22 +29,28 @@ type IteratorFunc func(fieldName string, field reflect.Value) error
 
 // StructIterator allows to traverse structures and call predefined function on each field.
 //
-// All loaders that use internal.StructIterator will not be able to use multiple names of fields!
+// Only one name is given to IteratorFunc, loaders keep other names with FieldNamesWithSeparator.
 type StructIterator struct {
 	Value        interface{}
 	ReflectValue reflect.Value
 	BaseName     string
 	// NoUpdate will specify that IteratorFunc cannot be run on fields with non-zero values.
+	// Struct fields are still iterated to reach their zero fields.
 	NoUpdate      bool
 	FieldNameFunc FieldNameFunc
 	IteratorFunc  IteratorFunc
+	// StructFunc is called for struct fields before iterating their inner fields, it is optional.
+	StructFunc IteratorFunc
 }
 
 // Iterate will iterate over every field and will execute FieldNameFunc and IteratorFunc on them.
 //
 // It will dive into all inner structs and will also iterate fields there.
 //
-// Pointer fields that are equal to nil will be initialized.
+// Pointer struct fields that are equal to nil will be initialized.
+// Other pointer fields are given to IteratorFunc as pointers,
+// setters like SetReflectValueString create the value, so a nil pointer is an unset field
+// and a pointer to zero value is an explicitly set field.
 //
 // Fields(but not structs) can implement encoding.TextUnmarshaler to be able to set values with custom logic.
 func (it StructIterator) Iterate() error {
@@ -63,13 +71,16 @@ func (it StructIterator) Iterate() error {
 
 		fieldName := it.FieldNameFunc(it.BaseName, structField)
 
-		if ShouldSkipField(toField, fieldName, it.NoUpdate) {
+		if ShouldSkipField(toField, fieldName, it.NoUpdate && !isStructOrPtr(toField.Type())) {
 			continue
 		}
 
-		// Set zero-value to valid pointer values when they are being processed.
-		if toField.Kind() == reflect.Ptr && toField.IsNil() {
-			toField.Set(reflect.New(toField.Type().Elem()))
+		// Set zero-value to pointer structs when they are being processed.
+		// Other pointers are given as is, they are set only when a value is found, so nil means unset.
+		if toField.Kind() == reflect.Ptr && IsStruct(toField.Type().Elem()) {
+			if toField.IsNil() {
+				toField.Set(reflect.New(toField.Type().Elem()))
+			}
 
 			toField = toField.Elem()
 		}
@@ -77,11 +88,19 @@ func (it StructIterator) Iterate() error {
 		// If it is a struct - try to set it's inner fields to.
 		// TODO: have a list of non-struct types for types like sql.Null* and null.*
 		if toField.Kind() == reflect.Struct && toField.Type() != TimeType {
+			if it.StructFunc != nil {
+				if err := it.StructFunc(fieldName, toField); err != nil {
+					return err
+				}
+			}
+
 			subIter := StructIterator{
 				ReflectValue:  toField.Addr(), // This is just simple solution for using pointer structs as inputs.
 				BaseName:      fieldName,
+				NoUpdate:      it.NoUpdate,
 				FieldNameFunc: it.FieldNameFunc,
 				IteratorFunc:  it.IteratorFunc,
+				StructFunc:    it.StructFunc,
 			}
 			// Do the same iteration on inner struct.
 			if err := subIter.Iterate(); err != nil {
@@ -132,11 +151,7 @@ func ShouldSkipField(field reflect.Value, fieldName string, noUpdate bool) bool
 // Optionally result modifying functions can be specified with opts argument.
 func FieldNameWithSeparator(tag, separator string, opts ...func(string) string) FieldNameFunc {
 	return func(outerName string, currentField reflect.StructField) string {
-		tagValue := TagValue(currentField, tag)[0]
-
-		if strings.Contains(tagValue, ",") {
-			tagValue = tagValue[:strings.Index(tagValue, ",")]
-		}
+		tagValue := strings.TrimSuffix(TagValue(currentField, tag)[0], DeprecatedMarker)
 
 		result := JoinFieldNames(outerName, tagValue, separator)
 		for i := range opts {
@@ -147,6 +162,62 @@ func FieldNameWithSeparator(tag, separator string, opts ...func(string) string)
 	}
 }
 
+// FieldName is one of the names of a field.
+type FieldName struct {
+	Name       string
+	Deprecated bool
+}
+
+// FieldNamesWithSeparator returns all names of the field listed in the tag, joined with all outer names.
+//
+// First name is the name that FieldNameWithSeparator returns when opts do not change the outer name.
+// Names with DeprecatedMarker suffix are marked as deprecated,
+// a name is also deprecated if its outer name is deprecated.
+//
+// Only the first name is used if tag is not found and the name comes from DefaultConfigTag,
+// because that tag also holds decoder options like "omitempty".
+//
+// opts are applied only to the inner names.
+func FieldNamesWithSeparator(tag, separator string, outerNames []FieldName, currentField reflect.StructField, opts ...func(string) string) []FieldName {
+	tagValues := TagValueByKeys(currentField.Tag, tag)
+	if tagValues == nil {
+		tagValues = TagValue(currentField, tag)[:1]
+	}
+
+	if len(outerNames) == 0 {
+		outerNames = []FieldName{{}}
+	}
+
+	names := make([]FieldName, 0, len(outerNames)*len(tagValues))
+
+	for _, outer := range outerNames {
+		for _, tagValue := range tagValues {
+			tagValue = strings.TrimSpace(tagValue)
+			if tagValue == "" {
+				continue
+			}
+
+			deprecated := strings.HasSuffix(tagValue, DeprecatedMarker)
+			tagValue = strings.TrimSuffix(tagValue, DeprecatedMarker)
+
+			for i := range opts {
+				tagValue = opts[i](tagValue)
+			}
+
+			names = append(names, FieldName{
+				Name:       JoinFieldNames(outer.Name, tagValue, separator),
+				Deprecated: deprecated || outer.Deprecated,
+			})
+		}
+	}
+
+	if len(names) == 0 {
+		names = append(names, FieldName{Name: JoinFieldNames(outerNames[0].Name, "", separator)})
+	}
+
+	return names
+}
+
 //nolint:golint
 func PlainFieldNameWithPath(outer string, currentField reflect.StructField) string {
 	if outer == "" {
@@ -238,6 +309,14 @@ func IsTagOmitted(tagVals []string) bool {
 	return len(tagVals) == 0
 }
 
+func isStructOrPtr(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return IsStruct(typ)
+}
+
 // IsStruct returns true if specified type is struct.
 //
 // Note: time.Time is not a struct by definition of this function!
@@ -258,8 +337,14 @@ func GetReflectElem(val interface{}) (reflect.Value, error) {
 
 // GetEnvWithFallback tries to load a value from an env variable by its name and gives back a given fallback value in
 // case the requested variable is empty.
-func GetEnvWithFallback(name string, fallback string) string {
-	if val := os.Getenv(name); val != "" {
+//
+// Variable is read from the source, process environment is used if source is nil.
+func GetEnvWithFallback(source EnvSource, name string, fallback string) string {
+	if source == nil {
+		source = OSEnv{}
+	}
+
+	if val, _ := source.LookupEnv(name); val != "" {
 		return val
 	}
 
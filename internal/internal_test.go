@@ -58,6 +58,29 @@ func TestEnvFieldName(t *testing.T) {
 	}
 }
 
+func TestFieldNamesWithSeparator(t *testing.T) {
+	field := reflect.StructField{Tag: `cfg:"url,omitempty" env:"db_url,database_url!deprecated"`}
+
+	names := FieldNamesWithSeparator("env", "_", nil, field, strings.ToUpper)
+	assert.Equal(t, []FieldName{
+		{Name: "DB_URL"},
+		{Name: "DATABASE_URL", Deprecated: true},
+	}, names)
+
+	outer := []FieldName{{Name: "APP"}, {Name: "OLD", Deprecated: true}}
+	names = FieldNamesWithSeparator("env", "_", outer, field, strings.ToUpper)
+	assert.Equal(t, []FieldName{
+		{Name: "APP_DB_URL"},
+		{Name: "APP_DATABASE_URL", Deprecated: true},
+		{Name: "OLD_DB_URL", Deprecated: true},
+		{Name: "OLD_DATABASE_URL", Deprecated: true},
+	}, names)
+
+	// cfg tag options are not names
+	names = FieldNamesWithSeparator("cmd", "-", nil, field, strings.ToLower)
+	assert.Equal(t, []FieldName{{Name: "url"}}, names)
+}
+
 func TestStructIterator_Iterate(t *testing.T) {
 	tests := []struct {
 		Name     string
@@ -103,6 +126,44 @@ func TestStructIterator_Iterate(t *testing.T) {
 			},
 			Result: &structWithEverything{A: "1", B: smallInnerStruct{C: 5}, C: &smallInnerStruct{Dur: 3 * time.Second}},
 		},
+		{
+			Name: "struct func before inner fields",
+			Iterator: StructIterator{
+				Value:         &structWithEverything{},
+				FieldNameFunc: FieldNameWithSeparator("env", "_", strings.ToUpper),
+				IteratorFunc: mapIterator(map[string]string{
+					"STRUCT_INNER": "5",
+				}),
+				StructFunc: func(fieldName string, field reflect.Value) error {
+					if fieldName == "STRUCT" {
+						field.Set(reflect.ValueOf(smallInnerStruct{C: 1, Dur: time.Second}))
+					}
+
+					return nil
+				},
+			},
+			Result: &structWithEverything{B: smallInnerStruct{C: 5, Dur: time.Second}, C: &smallInnerStruct{}},
+		},
+		{
+			Name: "no update in inner structs",
+			Iterator: StructIterator{
+				Value:         &structWithEverything{A: "set", B: smallInnerStruct{C: 1}, C: &smallInnerStruct{Dur: time.Second}},
+				NoUpdate:      true,
+				FieldNameFunc: FieldNameWithSeparator("env", "_", strings.ToUpper),
+				IteratorFunc: mapIterator(map[string]string{
+					"A":            "1",
+					"STRUCT_INNER": "5",
+					"STRUCT_DUR":   "2s",
+					"C_INNER":      "3",
+					"C_DUR":        "3s",
+				}),
+			},
+			Result: &structWithEverything{
+				A: "set",
+				B: smallInnerStruct{C: 1, Dur: 2 * time.Second},
+				C: &smallInnerStruct{C: 3, Dur: time.Second},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -234,7 +295,7 @@ func TestGetEnvWithFallback(t *testing.T) {
 			err := os.Setenv("TEST_ENVIRONMENT_VAR", scenario.value)
 			assert.NoError(t, err)
 
-			assert.Equal(t, scenario.expected, GetEnvWithFallback("TEST_ENVIRONMENT_VAR", scenario.fallback))
+			assert.Equal(t, scenario.expected, GetEnvWithFallback(nil, "TEST_ENVIRONMENT_VAR", scenario.fallback))
 		})
 	}
 }
@@ -0,0 +1,31 @@
+package internal
+
+// MergeMaps deep merges `src` into `dst` and returns `dst`.
+//
+// Nested maps are merged recursively, any other value in `src` replaces the value in `dst`.
+// If `dst` is nil, new map will be created.
+func MergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+
+	for k, v := range src {
+		srcMap, srcOK := v.(map[string]interface{})
+		dstMap, dstOK := dst[k].(map[string]interface{})
+
+		if srcOK && dstOK {
+			dst[k] = MergeMaps(dstMap, srcMap)
+
+			continue
+		}
+
+		if srcOK {
+			// copy to not share inner maps with the source
+			v = MergeMaps(nil, srcMap)
+		}
+
+		dst[k] = v
+	}
+
+	return dst
+}
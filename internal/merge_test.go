@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeMaps(t *testing.T) {
+	dst := map[string]interface{}{
+		"a": 1,
+		"inner": map[string]interface{}{
+			"b": 2,
+			"c": 3,
+		},
+	}
+
+	src := map[string]interface{}{
+		"a": 10,
+		"inner": map[string]interface{}{
+			"c": 30,
+		},
+		"new": map[string]interface{}{
+			"d": 4,
+		},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"a": 10,
+		"inner": map[string]interface{}{
+			"b": 2,
+			"c": 30,
+		},
+		"new": map[string]interface{}{
+			"d": 4,
+		},
+	}, MergeMaps(dst, src))
+
+	assert.Equal(t, map[string]interface{}{"x": 1}, MergeMaps(nil, map[string]interface{}{"x": 1}))
+}
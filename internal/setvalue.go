@@ -18,6 +18,9 @@ type TypeSetter func(input string, val reflect.Value) error
 //nolint:golint
 var SliceSeparator = ","
 
+// MapKeyValueSeparator separates key and value of the map items, like "a=1,b=2".
+var MapKeyValueSeparator = "="
+
 var typeMap = map[reflect.Type]TypeSetter{
 	reflect.TypeOf(time.Time{}): func(input string, val reflect.Value) error {
 		t, err := time.ParseInLocation(time.RFC3339, input, time.Local)
@@ -133,7 +136,11 @@ func SetStructFieldValue(fieldName, v string, strct reflect.Value) error {
 func SetReflectValueString(fieldName, v string, val reflect.Value) error {
 	const valueMsg = "value for val %q not a valid %q"
 
-	val = reflect.Indirect(val)
+	if val.Kind() == reflect.Ptr && GetCustomSetter(val.Type()) == nil {
+		return SetPointerValue(val, func(elem reflect.Value) error {
+			return SetReflectValueString(fieldName, v, elem)
+		})
+	}
 
 	kindName := val.Type().String()
 
@@ -171,6 +178,10 @@ func SetReflectValueString(fieldName, v string, val reflect.Value) error {
 		val.SetFloat(n)
 	case reflect.String:
 		val.SetString(v)
+	case reflect.Slice:
+		return setSliceString(fieldName, v, val)
+	case reflect.Map:
+		return setMapString(fieldName, v, val)
 	default:
 		return fmt.Errorf("val %q has unsupported type %q", fieldName, kindName)
 	}
@@ -178,6 +189,58 @@ func SetReflectValueString(fieldName, v string, val reflect.Value) error {
 	return nil
 }
 
+// setSliceString sets the slice from values separated with SliceSeparator.
+func setSliceString(fieldName, v string, val reflect.Value) error {
+	if v == "" {
+		return nil
+	}
+
+	parts := strings.Split(v, SliceSeparator)
+	sl := reflect.MakeSlice(val.Type(), len(parts), len(parts))
+
+	for i, part := range parts {
+		if err := SetReflectValueString(fieldName, strings.TrimSpace(part), sl.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	val.Set(sl)
+
+	return nil
+}
+
+// setMapString sets the map from key-value pairs separated with SliceSeparator, like "a=1,b=2".
+func setMapString(fieldName, v string, val reflect.Value) error {
+	if v == "" {
+		return nil
+	}
+
+	m := reflect.MakeMap(val.Type())
+
+	for _, part := range strings.Split(v, SliceSeparator) {
+		kv := strings.SplitN(part, MapKeyValueSeparator, 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("val %q item %q is not in key%svalue form", fieldName, part, MapKeyValueSeparator)
+		}
+
+		key := reflect.New(val.Type().Key()).Elem()
+		if err := SetReflectValueString(fieldName, strings.TrimSpace(kv[0]), key); err != nil {
+			return err
+		}
+
+		elem := reflect.New(val.Type().Elem()).Elem()
+		if err := SetReflectValueString(fieldName, strings.TrimSpace(kv[1]), elem); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(key, elem)
+	}
+
+	val.Set(m)
+
+	return nil
+}
+
 // TypeImplementsInterface checks if provided value's type,
 // or pointer(if possible to make pointer) to it's type implements specified interface type.
 //
@@ -193,3 +256,21 @@ func TypeImplementsInterface(val reflect.Value, interfaceType reflect.Type) refl
 
 	return reflect.Value{}
 }
+
+// SetPointerValue sets the value of pointer with setter, nil pointer is created only if setter succeeds.
+//
+// Existing value is copied to the new value before setter is called.
+func SetPointerValue(ptr reflect.Value, setter func(elem reflect.Value) error) error {
+	elem := reflect.New(ptr.Type().Elem())
+	if !ptr.IsNil() {
+		elem.Elem().Set(ptr.Elem())
+	}
+
+	if err := setter(elem.Elem()); err != nil {
+		return err
+	}
+
+	ptr.Set(elem)
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -62,3 +63,52 @@ func TestSetStruct(t *testing.T) {
 	refVal, _ := GetReflectElem(&testStruct)
 	assert.NotNil(t, SetStructFieldValue("Field", `{"test":false`, refVal))
 }
+
+func TestSetReflectValueString_Collections(t *testing.T) {
+	var s struct {
+		Ports   []int
+		Waits   []time.Duration
+		Labels  map[string]string
+		Weights map[string]float64
+	}
+
+	val := reflect.ValueOf(&s)
+
+	assert.NoError(t, SetStructFieldValue("Ports", "80, 443", val))
+	assert.NoError(t, SetStructFieldValue("Waits", "1s,2m", val))
+	assert.NoError(t, SetStructFieldValue("Labels", "team=core,env=prod", val))
+	assert.NoError(t, SetStructFieldValue("Weights", "a=0.5", val))
+
+	assert.Equal(t, []int{80, 443}, s.Ports)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Minute}, s.Waits)
+	assert.Equal(t, map[string]string{"team": "core", "env": "prod"}, s.Labels)
+	assert.Equal(t, map[string]float64{"a": 0.5}, s.Weights)
+
+	assert.EqualError(t, SetStructFieldValue("Labels", "team", val), `val "Labels" item "team" is not in key=value form`)
+	assert.Error(t, SetStructFieldValue("Ports", "80,x", val))
+}
+
+func TestSetReflectValueString_Pointer(t *testing.T) {
+	var s struct {
+		B *bool
+		D *time.Duration
+		I *int
+	}
+
+	v := reflect.ValueOf(&s).Elem()
+
+	assert.NoError(t, SetReflectValueString("b", "false", v.Field(0)))
+	assert.NoError(t, SetReflectValueString("d", "2s", v.Field(1)))
+	assert.Error(t, SetReflectValueString("i", "x", v.Field(2)))
+
+	if assert.NotNil(t, s.B) {
+		assert.False(t, *s.B)
+	}
+
+	if assert.NotNil(t, s.D) {
+		assert.Equal(t, 2*time.Second, *s.D)
+	}
+
+	// pointer is not created on error
+	assert.Nil(t, s.I)
+}
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"os"
 	"path"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/worldline-go/igconfig/codec"
@@ -21,6 +22,11 @@ var ConsulTag = "cfg"
 // ConsulConfigPathPrefix stores the default base path for secrets.
 var ConsulConfigPathPrefix = "finops"
 
+// ConsulAdditionalPaths are shared keys loaded in order before the application key, like VaultSecretAdditionalPaths.
+// Names are relative to the path prefix, like {Name: "generic", InnerPath: "tracing"}.
+// They must not be application specific!
+var ConsulAdditionalPaths []AdditionalPath
+
 var _ Loader = Consul{}
 
 var _ DynamicValuer = Consul{}
@@ -55,35 +61,213 @@ type Consul struct {
 	Decoder codec.Decoder
 	// Plan for dynamic changes
 	Plan Planer
+	// Tree loads all keys under '<prefix>/<appName>/' instead of one key,
+	// key hierarchy is mapped to nested fields like 'db/host' to DB.Host.
+	//
+	// Keys with an extension in FileDecoders like 'db.yaml' are documents, other values are used as string.
+	// Deeper keys override values of a document in the parent key.
+	//
+	// Note: this option is not used in DynamicValue.
+	Tree bool
+	// Hierarchical loads the parent keys of the appName before it,
+	// like "payments" and "payments/settlement" for "payments/settlement/worker".
+	//
+	// In Tree mode only the direct keys of a parent are loaded, keys of the children are skipped.
+	Hierarchical bool
 }
 
 // LoadWithContext retrieves data from Consul and decode response into 'to' struct.
+//
+// ConsulAdditionalPaths are loaded before the application key, then the parent keys if Hierarchical is set.
 func (l Consul) LoadWithContext(ctx context.Context, appName string, to interface{}) error {
-	if err := l.EnsureClient(); err != nil {
+	source := internal.EnvSourceFromContext(ctx)
+
+	if err := l.ensureClient(source); err != nil {
+		return err
+	}
+
+	if l.Decoder == nil {
+		l.Decoder = codec.YAML{}
+	}
+
+	if err := l.LoadFromReformat(ctx, ConsulAdditionalPaths, to); err != nil {
 		return err
 	}
 
+	if l.Hierarchical {
+		if err := l.loadPaths(ctx, parentPaths(appName), to, false); err != nil {
+			return err
+		}
+	}
+
+	key := path.Join(internal.GetEnvWithFallback(source, ConsulConfigPathPrefixEnv, ConsulConfigPathPrefix), appName)
+
+	if l.Tree {
+		mapping, err := l.treeMap(ctx, key, true)
+		if mapping == nil || err != nil {
+			return err
+		}
+
+		return l.decodeMap(ctx, mapping, key, to)
+	}
+
 	queryOptions := api.QueryOptions{}
-	data, _, err := l.Client.KV().Get(
-		path.Join(internal.GetEnvWithFallback(ConsulConfigPathPrefixEnv, ConsulConfigPathPrefix), appName),
-		queryOptions.WithContext(ctx),
-	)
+	data, _, err := l.Client.KV().Get(key, queryOptions.WithContext(ctx))
 	// If no data or err is returned - return early.
 	if data == nil || err != nil {
 		return err
 	}
 
+	if err := codec.LoadReaderWithSource(ctx, bytes.NewReader(data.Value), to, l.Decoder, ConsulTag, key); err != nil {
+		return fmt.Errorf("Consul.LoadWithContext error: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFromReformat loads the keys in order with InnerPath and Map of the paths.
+//
+// Names are relative to the path prefix, keys are loaded as tree if Tree is set.
+func (l Consul) LoadFromReformat(ctx context.Context, paths []AdditionalPath, to interface{}) error {
+	return l.loadPaths(ctx, paths, to, true)
+}
+
+// loadPaths loads the keys of the paths, nested keys are skipped in Tree mode if nested is false.
+func (l Consul) loadPaths(ctx context.Context, paths []AdditionalPath, to interface{}, nested bool) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	source := internal.EnvSourceFromContext(ctx)
+
+	if err := l.ensureClient(source); err != nil {
+		return err
+	}
+
 	if l.Decoder == nil {
 		l.Decoder = codec.YAML{}
 	}
 
-	if err := codec.LoadReaderWithDecoder(bytes.NewReader(data.Value), to, l.Decoder, ConsulTag); err != nil {
+	prefix := internal.GetEnvWithFallback(source, ConsulConfigPathPrefixEnv, ConsulConfigPathPrefix)
+
+	for _, p := range paths {
+		key := path.Join(prefix, p.Name)
+
+		mapping, err := l.readMap(ctx, key, nested)
+		if err != nil {
+			return err
+		}
+
+		if mapping == nil {
+			continue
+		}
+
+		if err := l.decodeMap(ctx, p.reformat(ctx, mapping), key, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readMap reads the key as a map, nil is returned if key is not found.
+func (l Consul) readMap(ctx context.Context, key string, nested bool) (map[string]interface{}, error) {
+	if l.Tree {
+		return l.treeMap(ctx, key, nested)
+	}
+
+	queryOptions := api.QueryOptions{}
+
+	data, _, err := l.Client.KV().Get(key, queryOptions.WithContext(ctx))
+	if data == nil || err != nil {
+		return nil, err
+	}
+
+	mapping, err := codec.DecodeMap(ctx, bytes.NewReader(data.Value), l.Decoder)
+	if err != nil {
+		return nil, fmt.Errorf("Consul.LoadWithContext error: %w", codec.NewDecodeError(key, data.Value, l.Decoder, err))
+	}
+
+	return mapping, nil
+}
+
+// decodeMap decodes the mapping of the key to 'to'.
+func (l Consul) decodeMap(ctx context.Context, mapping map[string]interface{}, key string, to interface{}) error {
+	if err := codec.MapDecoderStrict(ctx, &mapping, to, ConsulTag, key, codec.DecoderStrictMode(l.Decoder)); err != nil {
 		return fmt.Errorf("Consul.LoadWithContext error: %w", err)
 	}
 
 	return nil
 }
 
+// treeMap lists the keys under the key and decodes them as a nested map, nil is returned if there is no key.
+//
+// Only the direct keys are used if nested is false.
+func (l Consul) treeMap(ctx context.Context, key string, nested bool) (map[string]interface{}, error) {
+	queryOptions := api.QueryOptions{}
+
+	pairs, _, err := l.Client.KV().List(key+"/", queryOptions.WithContext(ctx))
+	if len(pairs) == 0 || err != nil {
+		return nil, err
+	}
+
+	mapping := map[string]interface{}{}
+
+	for _, pair := range pairs {
+		rel := strings.TrimPrefix(pair.Key, key+"/")
+		// folders have no value
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			continue
+		}
+
+		parts := strings.Split(rel, "/")
+		if !nested && len(parts) > 1 {
+			continue
+		}
+
+		last := len(parts) - 1
+
+		name, value, err := decodeTreeValue(parts[last], pair.Value)
+		if err != nil {
+			decodeErr := codec.NewDecodeError(pair.Key, pair.Value, FileDecoders[path.Ext(parts[last])], err)
+
+			return nil, fmt.Errorf("Consul.LoadWithContext error: %w", decodeErr)
+		}
+
+		parts[last] = name
+
+		for i := len(parts) - 1; i >= 0; i-- {
+			value = map[string]interface{}{parts[i]: value}
+		}
+
+		mapping = internal.MergeMaps(mapping, value.(map[string]interface{}))
+	}
+
+	if err := codec.DecryptMap(ctx, mapping); err != nil {
+		return nil, fmt.Errorf("Consul.LoadWithContext error: %w", err)
+	}
+
+	return mapping, nil
+}
+
+// decodeTreeValue decodes a value of the tree, value is returned as string if it is not a document.
+//
+// Keys with an extension in FileDecoders are documents, extension is removed from the returned name.
+// Other values are used as they are, so values like "[abc]" or certificates stay as string.
+func decodeTreeValue(name string, data []byte) (string, interface{}, error) {
+	decoder, ok := FileDecoders[path.Ext(name)]
+	if !ok {
+		return name, string(data), nil
+	}
+
+	var value interface{}
+	if err := decoder.Decode(bytes.NewReader(data), &value); err != nil {
+		return "", nil, err
+	}
+
+	return strings.TrimSuffix(name, path.Ext(name)), value, nil
+}
+
 // Load is just same as LoadWithContext without context.
 func (l Consul) Load(appName string, to interface{}) error {
 	return l.LoadWithContext(context.Background(), appName, to)
@@ -91,10 +275,14 @@ func (l Consul) Load(appName string, to interface{}) error {
 
 // EnsureClient creates and sets a Consul client if needed.
 func (l *Consul) EnsureClient() error {
+	return l.ensureClient(nil)
+}
+
+func (l *Consul) ensureClient(source EnvSource) error {
 	if l.Client == nil {
 		var err error
 
-		l.Client, err = NewConsulFromEnv()
+		l.Client, err = NewConsulFromEnvSource(source)
 		if err != nil {
 			return err
 		}
@@ -134,12 +322,71 @@ func NewConsul(addr string) (*api.Client, error) {
 // This function uses api.DefatulConfig(), which means that variables should be named as Consul expects them.
 // For example now CONSUL_ADDR should be set as CONSUL_HTTP_ADDR.
 func NewConsulFromEnv() (*api.Client, error) {
+	return NewConsulFromEnvSource(nil)
+}
+
+// NewConsulFromEnvSource creates a client from environmental variables in the source.
+//
+// Process environment is used if source is nil.
+// Variables of api.DefaultConfig() are read from the source, like CONSUL_HTTP_TOKEN and CONSUL_HTTP_SSL.
+// Consul client still fills empty TLS, namespace and partition settings from the process environment.
+func NewConsulFromEnvSource(source EnvSource) (*api.Client, error) {
+	if source == nil {
+		source = OSEnv{}
+	}
+
 	// for fast approach, if not exist pass
-	if _, ok := os.LookupEnv("CONSUL_HTTP_ADDR"); !ok {
+	addr, ok := source.LookupEnv(api.HTTPAddrEnvName)
+	if !ok {
 		return nil, fmt.Errorf("CONSUL_HTTP_ADDR not exist, err: %w", ErrNoClient)
 	}
 
-	return NewConsulWithConfig(api.DefaultConfig())
+	config := consulConfig(source)
+	config.Address = addr
+
+	return NewConsulWithConfig(config)
+}
+
+// consulConfig returns api.DefaultConfig() with the variables read from the source.
+func consulConfig(source EnvSource) *api.Config {
+	getEnv := func(key string) string {
+		v, _ := source.LookupEnv(key)
+
+		return v
+	}
+
+	config := api.DefaultConfig()
+
+	config.Scheme = "http"
+	if enabled, _ := strconv.ParseBool(getEnv(api.HTTPSSLEnvName)); enabled {
+		config.Scheme = "https"
+	}
+
+	config.Token = getEnv(api.HTTPTokenEnvName)
+	config.TokenFile = getEnv(api.HTTPTokenFileEnvName)
+
+	config.HttpAuth = nil
+	if auth := getEnv(api.HTTPAuthEnvName); auth != "" {
+		username, password, _ := strings.Cut(auth, ":")
+		config.HttpAuth = &api.HttpBasicAuth{Username: username, Password: password}
+	}
+
+	config.TLSConfig = api.TLSConfig{
+		Address:  getEnv(api.HTTPTLSServerName),
+		CAFile:   getEnv(api.HTTPCAFile),
+		CAPath:   getEnv(api.HTTPCAPath),
+		CertFile: getEnv(api.HTTPClientCert),
+		KeyFile:  getEnv(api.HTTPClientKey),
+	}
+
+	if verify, err := strconv.ParseBool(getEnv(api.HTTPSSLVerifyEnvName)); err == nil && !verify {
+		config.TLSConfig.InsecureSkipVerify = true
+	}
+
+	config.Namespace = getEnv(api.HTTPNamespaceEnvName)
+	config.Partition = getEnv(api.HTTPPartitionEnvName)
+
+	return config
 }
 
 // NewConsulWithConfig creates a client from a config.
@@ -38,14 +38,16 @@ import (
 //		// use v here
 //	}
 func (l Consul) DynamicValue(ctx context.Context, key string) (<-chan []byte, error) {
-	if err := l.EnsureClient(); err != nil {
+	source := internal.EnvSourceFromContext(ctx)
+
+	if err := l.ensureClient(source); err != nil {
 		return nil, err
 	}
 
 	if l.Plan == nil {
 		plan, err := watch.Parse(map[string]interface{}{
 			"type": "key",
-			"key":  path.Join(internal.GetEnvWithFallback(ConsulConfigPathPrefixEnv, ConsulConfigPathPrefix), key),
+			"key":  path.Join(internal.GetEnvWithFallback(source, ConsulConfigPathPrefixEnv, ConsulConfigPathPrefix), key),
 		})
 		if err != nil {
 			return nil, fmt.Errorf("wath.Parse %w", err)
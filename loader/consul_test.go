@@ -2,6 +2,7 @@ package loader
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -199,7 +200,7 @@ func (m *ConsulMock) RoundTrip(request *http.Request) (*http.Response, error) {
 	switch {
 	case strings.HasPrefix(reqURI, "/v1/kv/"):
 		key := strings.TrimPrefix(reqURI, path.Join("/v1/kv",
-			internal.GetEnvWithFallback(ConsulConfigPathPrefixEnv, ConsulConfigPathPrefix))+"/")
+			internal.GetEnvWithFallback(nil, ConsulConfigPathPrefixEnv, ConsulConfigPathPrefix))+"/")
 
 		kvResp, meta, err := m.Get(key, nil)
 
@@ -281,3 +282,220 @@ func generateMetaHeader(meta *api.QueryMeta) http.Header {
 
 	return h
 }
+
+func TestConsul_Tree(t *testing.T) {
+	t.Setenv(ConsulConfigPathPrefixEnv, "finops")
+
+	type config struct {
+		Name  string `cfg:"name"`
+		Cert  string `cfg:"cert"`
+		Label string `cfg:"label"`
+		DB    struct {
+			Host string `cfg:"host"`
+			Port int    `cfg:"port"`
+			Pool struct {
+				Size int `cfg:"size"`
+			} `cfg:"pool"`
+		} `cfg:"db"`
+		Tags   []string `cfg:"tags"`
+		Limits struct {
+			RPS   int `cfg:"rps"`
+			Burst int `cfg:"burst"`
+		} `cfg:"limits"`
+	}
+
+	pem := "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIUY2VydA==\nkey: value\n-----END CERTIFICATE-----\n"
+
+	kv := api.KVPairs{
+		{Key: "finops/tree-app/"},
+		{Key: "finops/tree-app/db/"},
+		{Key: "finops/tree-app/db/host", Value: []byte("localhost")},
+		{Key: "finops/tree-app/cert", Value: []byte(pem)},
+		{Key: "finops/tree-app/db/pool.yaml", Value: []byte("size: 5\nidle: 1\n")},
+		{Key: "finops/tree-app/db/port", Value: []byte("5432")},
+		{Key: "finops/tree-app/label", Value: []byte("[abc]")},
+		{Key: "finops/tree-app/limits.yaml", Value: []byte("{rps: 100, burst: 10}")},
+		{Key: "finops/tree-app/limits/burst", Value: []byte("20")},
+		{Key: "finops/tree-app/name", Value: []byte("my app: v1")},
+		{Key: "finops/tree-app/tags.json", Value: []byte(`["a", "b"]`)},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/finops/tree-app/" || !r.URL.Query().Has("recurse") {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(kv))
+	}))
+	defer server.Close()
+
+	cl, err := NewConsul(server.URL)
+	assert.NoError(t, err)
+
+	var c config
+
+	assert.NoError(t, Consul{Client: cl, Tree: true}.Load("tree-app", &c))
+
+	assert.Equal(t, "my app: v1", c.Name)
+	assert.Equal(t, pem, c.Cert)
+	assert.Equal(t, "[abc]", c.Label)
+	assert.Equal(t, "localhost", c.DB.Host)
+	assert.Equal(t, 5432, c.DB.Port)
+	assert.Equal(t, 5, c.DB.Pool.Size)
+	assert.Equal(t, []string{"a", "b"}, c.Tags)
+	assert.Equal(t, 100, c.Limits.RPS)
+	assert.Equal(t, 20, c.Limits.Burst)
+
+	// no keys
+	assert.NoError(t, Consul{Client: cl, Tree: true}.Load("missing", &c))
+}
+
+func TestConsul_AdditionalPaths(t *testing.T) {
+	t.Setenv(ConsulConfigPathPrefixEnv, "finops")
+
+	defer func(paths []AdditionalPath) { ConsulAdditionalPaths = paths }(ConsulAdditionalPaths)
+
+	ConsulAdditionalPaths = []AdditionalPath{
+		{Name: "generic"},
+		{Name: "shared/telemetry", InnerPath: "otel", Map: "telemetry"},
+		{Name: "missing"},
+	}
+
+	type config struct {
+		Name string `cfg:"name"`
+		Log  struct {
+			Level  string `cfg:"level"`
+			Format string `cfg:"format"`
+		} `cfg:"log"`
+		Telemetry struct {
+			Endpoint string `cfg:"endpoint"`
+		} `cfg:"telemetry"`
+	}
+
+	mock := &ConsulMock{kv: map[string][]byte{
+		"generic":          []byte("log:\n  level: info\n  format: json\n"),
+		"shared/telemetry": []byte("otel:\n  endpoint: collector:4317\n"),
+		"app":              []byte("name: app\nlog:\n  level: debug\n"),
+	}}
+
+	var c config
+
+	assert.NoError(t, Consul{Client: NewConsulMock(mock)}.Load("app", &c))
+
+	assert.Equal(t, "app", c.Name)
+	assert.Equal(t, "debug", c.Log.Level)
+	assert.Equal(t, "json", c.Log.Format)
+	assert.Equal(t, "collector:4317", c.Telemetry.Endpoint)
+}
+
+func TestConsul_Hierarchical(t *testing.T) {
+	t.Setenv(ConsulConfigPathPrefixEnv, "finops")
+
+	type config struct {
+		Log struct {
+			Level string `cfg:"level"`
+		} `cfg:"log"`
+		Timeout string `cfg:"timeout"`
+		Queue   string `cfg:"queue"`
+	}
+
+	mock := &ConsulMock{kv: map[string][]byte{
+		"payments":                   []byte("log: {level: info}\ntimeout: 5s\n"),
+		"payments/settlement":        []byte("timeout: 10s\n"),
+		"payments/settlement/worker": []byte("queue: settle\n"),
+	}}
+
+	var c config
+
+	assert.NoError(t, Consul{Client: NewConsulMock(mock), Hierarchical: true}.Load("payments/settlement/worker", &c))
+
+	assert.Equal(t, "info", c.Log.Level)
+	assert.Equal(t, "10s", c.Timeout)
+	assert.Equal(t, "settle", c.Queue)
+
+	assert.Equal(t, []AdditionalPath{{Name: "a"}, {Name: "a/b"}}, parentPaths("/a/b/c/"))
+	assert.Empty(t, parentPaths("app"))
+}
+
+func TestConsul_HierarchicalTree(t *testing.T) {
+	t.Setenv(ConsulConfigPathPrefixEnv, "finops")
+
+	type config struct {
+		Timeout string `cfg:"timeout"`
+		Queue   string `cfg:"queue"`
+		Log     struct {
+			Level string `cfg:"level"`
+		} `cfg:"log"`
+	}
+
+	kv := api.KVPairs{
+		{Key: "finops/payments/log.yaml", Value: []byte("level: info\n")},
+		{Key: "finops/payments/timeout", Value: []byte("5s")},
+		{Key: "finops/payments/refunds/queue", Value: []byte("refund")},
+		{Key: "finops/payments/refunds/timeout", Value: []byte("1s")},
+		{Key: "finops/payments/worker/queue", Value: []byte("settle")},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+		var pairs api.KVPairs
+
+		for _, pair := range kv {
+			if strings.HasPrefix(pair.Key, prefix) {
+				pairs = append(pairs, pair)
+			}
+		}
+
+		if len(pairs) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(pairs))
+	}))
+	defer server.Close()
+
+	cl, err := NewConsul(server.URL)
+	assert.NoError(t, err)
+
+	var c config
+
+	assert.NoError(t, Consul{Client: cl, Tree: true, Hierarchical: true}.Load("payments/worker", &c))
+
+	assert.Equal(t, "5s", c.Timeout)
+	assert.Equal(t, "settle", c.Queue)
+	assert.Equal(t, "info", c.Log.Level)
+
+	mapping, err := Consul{Client: cl, Tree: true, Decoder: codec.YAML{}}.readMap(context.Background(), "finops/payments", false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"log": map[string]interface{}{"level": "info"}, "timeout": "5s"}, mapping)
+}
+
+func TestConsulConfig(t *testing.T) {
+	t.Setenv(api.HTTPTokenEnvName, "os-token")
+	t.Setenv(api.HTTPNamespaceEnvName, "os-namespace")
+
+	config := consulConfig(EnvMap{
+		api.HTTPTokenEnvName:     "source-token",
+		api.HTTPSSLEnvName:       "true",
+		api.HTTPAuthEnvName:      "user:pass",
+		api.HTTPCAFile:           "/certs/ca.pem",
+		api.HTTPSSLVerifyEnvName: "false",
+	})
+
+	assert.Equal(t, "source-token", config.Token)
+	assert.Equal(t, "https", config.Scheme)
+	assert.Equal(t, &api.HttpBasicAuth{Username: "user", Password: "pass"}, config.HttpAuth)
+	assert.Equal(t, "/certs/ca.pem", config.TLSConfig.CAFile)
+	assert.True(t, config.TLSConfig.InsecureSkipVerify)
+	assert.Empty(t, config.Namespace)
+
+	config = consulConfig(OSEnv{})
+	assert.Equal(t, "os-token", config.Token)
+	assert.Equal(t, "os-namespace", config.Namespace)
+	assert.Equal(t, "http", config.Scheme)
+}
@@ -1,10 +1,18 @@
 package loader
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
+	"github.com/worldline-go/igconfig/codec"
 	"github.com/worldline-go/igconfig/internal"
 )
 
@@ -13,16 +21,44 @@ var _ Loader = Default{}
 // DefaultTag is a tag name for default value.
 const DefaultTag = "default"
 
+// DefaultFilePrefix is the prefix of struct default to read defaults from a file, like 'default:"file:server.yaml"'.
+const DefaultFilePrefix = "file:"
+
 // Default is a loader that loads config struct fields with their default value as defined in the tags.
-type Default struct{}
+//
+// Slices, maps and structs can have JSON or YAML values, like 'default:"{a: 1, b: 2}"' or 'default:"[1s, 2s]"'.
+//
+// Struct fields can have a YAML value or a file with DefaultFilePrefix for the whole struct,
+// file is decoded with FileDecoders. Only zero fields are set and tags of inner fields are applied after.
+type Default struct {
+	// FS to read default files, like an embed.FS. Default is the working directory.
+	FS fs.FS
+}
 
 // LoadWithContext loads the config struct fields with their default value as defined in the tags.
-func (l Default) LoadWithContext(_ context.Context, _ string, to interface{}) error {
+func (l Default) LoadWithContext(ctx context.Context, _ string, to interface{}) error {
+	structDefaults := make(map[string]string)
+
 	it := internal.StructIterator{
-		Value:         to,
-		NoUpdate:      true,
-		FieldNameFunc: l.FieldNameFunc,
-		IteratorFunc:  l.IteratorFunc,
+		Value:    to,
+		NoUpdate: true,
+		FieldNameFunc: func(outer string, field reflect.StructField) string {
+			name := l.FieldNameFunc(outer, field)
+
+			if v := internal.TagValueByKeys(field.Tag, DefaultTag); v != nil && isDefaultStruct(field.Type, v) {
+				structDefaults[name] = strings.Join(v, ",")
+			}
+
+			return name
+		},
+		IteratorFunc: l.IteratorFunc,
+		StructFunc: func(fieldName string, field reflect.Value) error {
+			if v, ok := structDefaults[fieldName]; ok {
+				return l.setStructDefault(ctx, fieldName, v, field)
+			}
+
+			return nil
+		},
 	}
 
 	return it.Iterate()
@@ -38,9 +74,8 @@ func (l Default) Load(_ string, to interface{}) error {
 // This function will return string in format of <field_name>:<default_value>
 // or just "-" if no default value is defined.
 func (l Default) FieldNameFunc(outer string, field reflect.StructField) string {
-	isStruct := internal.IsStruct(field.Type)
-
 	v := internal.TagValueByKeys(field.Tag, DefaultTag)
+	isStruct := isDefaultStruct(field.Type, v)
 	if internal.IsTagOmitted(v) && !isStruct { // If no default value and is not struct - skip such field.
 		return "-"
 	}
@@ -51,6 +86,7 @@ func (l Default) FieldNameFunc(outer string, field reflect.StructField) string {
 		return "-"
 	}
 
+	// Struct default is applied in LoadWithContext.
 	fieldName := internal.PlainFieldNameWithPath(outer, field)
 	if isStruct {
 		return fieldName
@@ -63,5 +99,83 @@ func (l Default) FieldNameFunc(outer string, field reflect.StructField) string {
 func (l Default) IteratorFunc(fieldName string, field reflect.Value) error {
 	sl := strings.SplitN(fieldName, ":", 2)
 
-	return internal.SetReflectValueString(sl[0], sl[1], field)
+	return setValueString(sl[0], sl[1], field)
+}
+
+// isDefaultStruct returns true for structs and pointer structs with a default tag.
+func isDefaultStruct(typ reflect.Type, tagValues []string) bool {
+	if typ.Kind() == reflect.Ptr && !internal.IsTagOmitted(tagValues) {
+		typ = typ.Elem()
+	}
+
+	return internal.IsStruct(typ)
+}
+
+// setStructDefault decodes the struct default and sets the zero fields.
+func (l Default) setStructDefault(ctx context.Context, fieldName, v string, field reflect.Value) error {
+	var data interface{}
+
+	if fileName, ok := strings.CutPrefix(v, DefaultFilePrefix); ok {
+		mapping, err := l.readFile(ctx, fileName)
+		if err != nil {
+			return fmt.Errorf("default of %q: %w", fieldName, err)
+		}
+
+		data = mapping
+	} else if err := yaml.Unmarshal([]byte(v), &data); err != nil {
+		return fmt.Errorf("default of %q decode: %w", fieldName, err)
+	}
+
+	out := reflect.New(field.Type())
+	if err := codec.MapDecoder(data, out.Interface(), FileTag); err != nil {
+		return fmt.Errorf("default of %q decode: %w", fieldName, err)
+	}
+
+	fillZero(field, out.Elem())
+
+	return nil
+}
+
+func (l Default) readFile(ctx context.Context, fileName string) (map[string]interface{}, error) {
+	decoder, ok := FileDecoders[filepath.Ext(fileName)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoDecoder, filepath.Ext(fileName))
+	}
+
+	var data []byte
+
+	var err error
+
+	if l.FS != nil {
+		data, err = fs.ReadFile(l.FS, fileName)
+	} else {
+		data, err = os.ReadFile(fileName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := codec.DecodeMap(ctx, bytes.NewReader(data), decoder)
+	if err != nil {
+		return nil, codec.NewDecodeError(fileName, data, decoder, err)
+	}
+
+	return mapping, nil
+}
+
+// fillZero sets zero values of dst with src, structs are filled field by field.
+func fillZero(dst, src reflect.Value) {
+	switch {
+	case dst.Kind() == reflect.Struct && dst.Type() != internal.TimeType:
+		for i := 0; i < dst.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				fillZero(dst.Field(i), src.Field(i))
+			}
+		}
+	case dst.Kind() == reflect.Ptr && !dst.IsNil() && !src.IsNil():
+		fillZero(dst.Elem(), src.Elem())
+	case dst.IsZero():
+		dst.Set(src)
+	}
 }
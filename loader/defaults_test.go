@@ -1,7 +1,10 @@
 package loader_test
 
 import (
+	"path/filepath"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -71,3 +74,110 @@ func TestDefault_WithInnerStruct(t *testing.T) {
 			},
 		}, v)
 }
+
+func TestDefault_Complex(t *testing.T) {
+	type server struct {
+		Host    string        `cfg:"host"`
+		Port    int           `cfg:"port" default:"80"`
+		Timeout time.Duration `cfg:"timeout"`
+	}
+
+	type config struct {
+		Limits  map[string]int    `default:"{read: 10, write: 5}"`
+		Waits   []time.Duration   `default:"[1s, 2m]"`
+		Names   []string          `default:"[\"a,b\", c]"`
+		Labels  map[string]string `default:"team=core,env=dev"`
+		Server  server            `default:"{host: localhost, timeout: 5s}"`
+		Backup  *server           `default:"file:testdata/server.yaml"`
+		Primary server            `default:"file:server.yaml"`
+	}
+
+	fsys := fstest.MapFS{
+		"server.yaml":          {Data: []byte("host: primary\nport: 9090\n")},
+		"testdata/server.yaml": {Data: []byte("host: backup\n")},
+	}
+
+	c := config{Server: server{Host: "set"}, Backup: &server{Port: 1}}
+
+	require.NoError(t, (loader.Default{FS: fsys}).Load("", &c))
+
+	assert.Equal(t, config{
+		Limits:  map[string]int{"read": 10, "write": 5},
+		Waits:   []time.Duration{time.Second, 2 * time.Minute},
+		Names:   []string{"a,b", "c"},
+		Labels:  map[string]string{"team": "core", "env": "dev"},
+		Server:  server{Host: "set", Port: 80, Timeout: 5 * time.Second},
+		Backup:  &server{Host: "backup", Port: 1},
+		Primary: server{Host: "primary", Port: 9090},
+	}, c)
+
+	var missing struct {
+		Server server `default:"file:missing.yaml"`
+	}
+
+	assert.ErrorContains(t, (loader.Default{FS: fsys}).Load("", &missing), `default of "Server"`)
+}
+
+func TestDefault_Pointers(t *testing.T) {
+	type config struct {
+		Debug   *bool   `cfg:"debug" default:"true"`
+		Retries *int    `cfg:"retries" default:"3"`
+		Name    *string `cfg:"name"`
+		Cache   *bool   `cfg:"cache" default:"true"`
+		Workers int     `cfg:"workers" default:"4"`
+	}
+
+	var c config
+
+	env := loader.Env{Source: loader.EnvMap{"DEBUG": "false", "RETRIES": "0", "WORKERS": "0"}}
+	require.NoError(t, env.Load("", &c))
+
+	// not found values keep nil
+	assert.Nil(t, c.Name)
+	assert.Nil(t, c.Cache)
+
+	var rest []string
+
+	require.NoError(t, (loader.Flags{Rest: &rest}).LoadSlice(&c, []string{"--cache=false"}))
+	require.NoError(t, (loader.Default{}).Load("", &c))
+
+	// explicitly set zero values are kept, plain zero values get the default
+	require.NotNil(t, c.Debug)
+	assert.False(t, *c.Debug)
+	require.NotNil(t, c.Retries)
+	assert.Equal(t, 0, *c.Retries)
+	require.NotNil(t, c.Cache)
+	assert.False(t, *c.Cache)
+	assert.Nil(t, c.Name)
+	assert.Equal(t, 4, c.Workers)
+
+	var d config
+
+	require.NoError(t, (loader.Flags{}).LoadSlice(&d, []string{"--debug", "--name", "x"}))
+	require.NoError(t, (loader.Default{}).Load("", &d))
+
+	assert.True(t, *d.Debug)
+	assert.Equal(t, "x", *d.Name)
+	assert.Equal(t, 3, *d.Retries)
+}
+
+func TestDefault_Null(t *testing.T) {
+	type config struct {
+		Debug   *bool `cfg:"debug" default:"true"`
+		Retries *int  `cfg:"retries" default:"3"`
+	}
+
+	dir := writeFiles(t, map[string]string{"config.yaml": "debug: null\n"})
+
+	var c config
+
+	require.NoError(t, (loader.Env{Source: loader.EnvMap{"DEBUG": "false", "RETRIES": "0"}}).Load("", &c))
+	// null in a later loader unsets the value, so it gets the default
+	require.NoError(t, (loader.File{}).LoadFile(filepath.Join(dir, "config.yaml"), &c))
+	require.NoError(t, (loader.Default{}).Load("", &c))
+
+	require.NotNil(t, c.Debug)
+	assert.True(t, *c.Debug)
+	require.NotNil(t, c.Retries)
+	assert.Equal(t, 0, *c.Retries)
+}
@@ -2,10 +2,16 @@ package loader
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
+	"github.com/rs/zerolog/log"
+
 	"github.com/worldline-go/igconfig/internal"
 )
 
@@ -14,45 +20,368 @@ var _ Loader = Env{}
 // EnvTag is a tag name for environment variable.
 const EnvTag = "env"
 
+// ErrFileNotAllowed is returned when the file of the variable is not in the allowed directories.
+var ErrFileNotAllowed = errors.New("file is not in allowed directories")
+
+// EnvFileSuffix is the default suffix of the variables holding a file to read the value from.
+var EnvFileSuffix = "_FILE"
+
 // Env allows to load values from environmental variables.
 //
 // For consistency and best-practices of Linux environmental variables
 // only upper-case values will be used. Even if in tag it is specified in lower-case.
 //
 // Variable name will be upper-cased when doing lookup. No other cases are checked.
-type Env struct{}
+type Env struct {
+	// Prefix is added to all variable names with '_' separator, like PREFIX_PORT.
+	Prefix string
+	// AppNamePrefix derives Prefix from appName when Prefix is empty.
+	// Name is uppercased and characters other than letters and digits converted to '_',
+	// so "payments/worker" becomes PAYMENTS_WORKER.
+	AppNamePrefix bool
+	// PrefixFallback looks up the unprefixed name when prefixed variable is not set.
+	PrefixFallback bool
+	// FileSuffix is the suffix of the variable holding a file to read the value from, like DB_PASSWORD_FILE.
+	// Default is EnvFileSuffix. Variables that are names of other fields are not used as file variables.
+	FileSuffix string
+	// NoFileSuffix disables reading values from files.
+	NoFileSuffix bool
+	// FileDirs restricts the files read with FileSuffix variables to these directories.
+	// Empty means any file can be read.
+	FileDirs []string
+	// Source of the variables, default is the source in the context (see WithEnvSource) or the process environment.
+	Source EnvSource
 
-// LoadWithContext implementation for Env ignores variable name prefix.
-// We want to load env vars without Vault like prefix.
-func (l Env) LoadWithContext(_ context.Context, _ string, to interface{}) error {
-	it := internal.StructIterator{
-		Value:         to,
-		FieldNameFunc: l.FieldNameFunc,
-		IteratorFunc:  l.IteratorFunc,
+	// names holds all names of the fields with the first name as key.
+	names map[string][]internal.FieldName
+	// fields holds variable names of all fields, they are not checked as file variables.
+	fields map[string]bool
+}
+
+// LoadWithContext loads values from environment variables.
+//
+// Variable names are not prefixed with appName unless Prefix or AppNamePrefix is set.
+func (l Env) LoadWithContext(ctx context.Context, appName string, to interface{}) error {
+	if l.Prefix == "" && l.AppNamePrefix {
+		l.Prefix = EnvPrefix(appName)
 	}
 
-	return it.Iterate()
+	if l.Source == nil {
+		l.Source = internal.EnvSourceFromContext(ctx)
+	}
+
+	l.names = make(map[string][]internal.FieldName)
+	l.fields = l.fieldVarNames(to)
+
+	return l.iterate(ctx, internal.StructIterator{Value: to})
 }
 
 // Load is just same as LoadWithContext without context.
-func (l Env) Load(_ string, to interface{}) error {
-	return l.LoadWithContext(context.TODO(), "", to)
+func (l Env) Load(appName string, to interface{}) error {
+	return l.LoadWithContext(context.TODO(), appName, to)
+}
+
+// iterate sets the fields of the iterator with the context used for logging.
+func (l Env) iterate(ctx context.Context, it internal.StructIterator) error {
+	it.FieldNameFunc = l.FieldNameFunc
+	it.IteratorFunc = func(fieldName string, field reflect.Value) error {
+		return l.setField(ctx, fieldName, field)
+	}
+	it.StructFunc = func(fieldName string, field reflect.Value) error {
+		return l.setStruct(ctx, fieldName, field)
+	}
+
+	return it.Iterate()
 }
 
 // FieldNameFunc returns a field function which will get name from `env` tag,
 // concatenated with '_'(underscore) and uppercased.
+//
+// Top level names are prefixed with Prefix.
+//
+// Tag can list multiple names, like `env:"DB_URL,DATABASE_URL!deprecated"`.
+// Only the first name is returned, others are used in IteratorFunc when called from LoadWithContext.
 func (l Env) FieldNameFunc(outer string, field reflect.StructField) string {
-	return internal.FieldNameWithSeparator(EnvTag, "_", strings.ToUpper)(outer, field)
+	outerNames := l.names[outer]
+	if outerNames == nil {
+		if outer == "" && l.Prefix != "" {
+			outer = strings.TrimSuffix(strings.ToUpper(l.Prefix), "_")
+		}
+
+		if outer != "" {
+			outerNames = []internal.FieldName{{Name: outer}}
+		}
+	}
+
+	// outer is not uppercased again to keep map keys as they are.
+	names := internal.FieldNamesWithSeparator(EnvTag, "_", outerNames, field, strings.ToUpper)
+
+	if l.names != nil {
+		l.names[names[0].Name] = names
+	}
+
+	return names[0].Name
 }
 
 // IteratorFunc sets a field to a value from environment.
 //
+// Names of the field are checked in order, a warning is logged if a deprecated name is used.
+//
+// If variable is not set but the variable with FileSuffix is set,
+// value is read from that file with trailing new line trimmed. Missing files are skipped with a warning.
+//
+// Slice and map fields that are not defined directly are set from indexed or keyed variables,
+// see setCollection.
+//
 // If field is not defined in environment - it is no-op.
 func (l Env) IteratorFunc(fieldName string, field reflect.Value) error {
-	val, ok := os.LookupEnv(fieldName)
+	return l.setField(context.Background(), fieldName, field)
+}
+
+func (l Env) setField(ctx context.Context, fieldName string, field reflect.Value) error {
+	val, ok, err := l.lookupNames(ctx, fieldName)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return setValueString(fieldName, val, field)
+	}
+
+	for _, name := range l.fieldNames(fieldName) {
+		ok, err := l.setCollection(ctx, name.Name, field)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			warnDeprecatedEnv(ctx, name, fieldName)
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// StructFunc sets a struct field from a JSON or YAML flow value, like LIMITS='{"rps":100}'.
+//
+// Variables of the inner fields are applied after it.
+func (l Env) StructFunc(fieldName string, field reflect.Value) error {
+	return l.setStruct(context.Background(), fieldName, field)
+}
+
+func (l Env) setStruct(ctx context.Context, fieldName string, field reflect.Value) error {
+	val, ok, err := l.lookupNames(ctx, fieldName)
+	if err != nil || !ok || !isDecodeValue(field, val) {
+		return err
+	}
+
+	return decodeValueString(fieldName, val, field)
+}
+
+// lookupNames gets the value of the first name of the field that is set.
+func (l Env) lookupNames(ctx context.Context, fieldName string) (string, bool, error) {
+	for _, name := range l.fieldNames(fieldName) {
+		val, ok := l.lookup(name.Name)
+		if !ok {
+			var err error
+
+			val, ok, err = l.lookupFile(ctx, name.Name)
+			if err != nil {
+				return "", false, err
+			}
+		}
+
+		if ok {
+			warnDeprecatedEnv(ctx, name, fieldName)
+
+			return val, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func (l Env) fieldNames(fieldName string) []internal.FieldName {
+	if names, ok := l.names[fieldName]; ok {
+		return names
+	}
+
+	return []internal.FieldName{{Name: fieldName}}
+}
+
+func warnDeprecatedEnv(ctx context.Context, name internal.FieldName, fieldName string) {
+	if !name.Deprecated {
+		return
+	}
+
+	log.Ctx(ctx).Warn().Str("env", name.Name).Str("use", fieldName).Msg("deprecated environment variable")
+}
+
+// lookupFile reads the value from the file in the variable with FileSuffix.
+func (l Env) lookupFile(ctx context.Context, name string) (string, bool, error) {
+	suffix := l.fileSuffix()
+	fileVar := name + suffix
+	if suffix == "" || l.fields[fileVar] {
+		return "", false, nil
+	}
+
+	fileName, ok := l.lookup(fileVar)
 	if !ok {
+		return "", false, nil
+	}
+
+	data, err := l.readFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Ctx(ctx).Warn().Str("env", fileVar).Str("file", fileName).Msg("file of environment variable not found")
+
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("env %s: %w", fileVar, err)
+	}
+
+	val := strings.TrimSuffix(string(data), "\n")
+	val = strings.TrimSuffix(val, "\r")
+
+	return val, true, nil
+}
+
+func (l Env) fileSuffix() string {
+	if l.NoFileSuffix {
+		return ""
+	}
+
+	if l.FileSuffix != "" {
+		return l.FileSuffix
+	}
+
+	return EnvFileSuffix
+}
+
+// readFile reads the file if it is inside one of FileDirs.
+func (l Env) readFile(fileName string) ([]byte, error) {
+	if err := l.checkFileDir(fileName); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(fileName)
+}
+
+// checkFileDir checks the file is inside one of FileDirs, symlinks are resolved.
+func (l Env) checkFileDir(fileName string) error {
+	if len(l.FileDirs) == 0 {
+		return nil
+	}
+
+	realFile, err := filepath.EvalSymlinks(fileName)
+	if err != nil {
+		return err
+	}
+
+	realFile, err = filepath.Abs(realFile)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range l.FileDirs {
+		realDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+
+		realDir, err = filepath.Abs(realDir)
+		if err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(realDir, realFile)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrFileNotAllowed, fileName)
+}
+
+// lookup gets the variable, fallbacks to unprefixed name if PrefixFallback is set.
+func (l Env) lookup(name string) (string, bool) {
+	source := l.source()
+
+	if val, ok := source.LookupEnv(name); ok {
+		return val, true
+	}
+
+	if l.Prefix == "" || !l.PrefixFallback {
+		return "", false
+	}
+
+	prefix := strings.TrimSuffix(strings.ToUpper(l.Prefix), "_") + "_"
+	if !strings.HasPrefix(name, prefix) {
+		return "", false
+	}
+
+	return source.LookupEnv(strings.TrimPrefix(name, prefix))
+}
+
+func (l Env) source() EnvSource {
+	if l.Source != nil {
+		return l.Source
+	}
+
+	return internal.OSEnv{}
+}
+
+// fieldVarNames returns all variable names of the fields of 'to', it does not change 'to'.
+func (l Env) fieldVarNames(to interface{}) map[string]bool {
+	if l.fileSuffix() == "" {
+		return nil
+	}
+
+	typ := reflect.TypeOf(to)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return nil
+	}
+
+	l.names = make(map[string][]internal.FieldName)
+
+	it := internal.StructIterator{
+		ReflectValue:  reflect.New(typ.Elem()),
+		FieldNameFunc: l.FieldNameFunc,
+		IteratorFunc:  func(string, reflect.Value) error { return nil },
+	}
+
+	if err := it.Iterate(); err != nil {
 		return nil
 	}
 
-	return internal.SetReflectValueString(fieldName, val, field)
+	fields := make(map[string]bool)
+
+	for _, names := range l.names {
+		for _, name := range names {
+			fields[name.Name] = true
+		}
+	}
+
+	return fields
+}
+
+// EnvPrefix converts appName to an environment variable prefix.
+//
+// Name is uppercased and characters other than letters and digits converted to '_'.
+func EnvPrefix(appName string) string {
+	appName = strings.Trim(strings.TrimSpace(appName), "/")
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, appName)
 }
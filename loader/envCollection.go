@@ -0,0 +1,256 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// EnvMaxSliceIndex is the biggest index of a slice element in variable names, like PORTS_1023.
+var EnvMaxSliceIndex = 1023
+
+// ErrSliceIndex is returned when a slice index in a variable name is bigger than EnvMaxSliceIndex.
+var ErrSliceIndex = errors.New("slice index is out of range")
+
+// setCollection sets slice and map fields from indexed or keyed variables.
+//
+// Slice elements are set with NAME_<index>[_FIELD] variables, index is the position in the slice.
+// Slice grows to the biggest index with zero elements, ErrSliceIndex is returned if it is bigger than EnvMaxSliceIndex.
+// Map elements are set with NAME_<key>[_FIELD] variables, key is used as it is in the variable name.
+//
+// Variables are discovered by scanning the environment, returns true if any variable found.
+func (l Env) setCollection(ctx context.Context, fieldName string, field reflect.Value) (bool, error) {
+	switch field.Kind() {
+	case reflect.Slice:
+		return l.setSlice(ctx, fieldName, field)
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String {
+			return false, nil
+		}
+
+		return l.setMap(ctx, fieldName, field)
+	default:
+		return false, nil
+	}
+}
+
+func (l Env) setSlice(ctx context.Context, fieldName string, field reflect.Value) (bool, error) {
+	prefix := fieldName + "_"
+
+	indexes := map[int]struct{}{}
+
+	for _, name := range l.namesWithPrefix(prefix) {
+		rest := strings.TrimPrefix(name, prefix)
+		if i := strings.Index(rest, "_"); i != -1 {
+			rest = rest[:i]
+		}
+
+		index, err := strconv.Atoi(rest)
+		if err != nil || index < 0 {
+			continue
+		}
+
+		if index > EnvMaxSliceIndex {
+			return false, fmt.Errorf("%w: %s, max index is %d", ErrSliceIndex, name, EnvMaxSliceIndex)
+		}
+
+		indexes[index] = struct{}{}
+	}
+
+	if len(indexes) == 0 {
+		return false, nil
+	}
+
+	sorted := make([]int, 0, len(indexes))
+	for index := range indexes {
+		sorted = append(sorted, index)
+	}
+
+	sort.Ints(sorted)
+
+	if size := sorted[len(sorted)-1] + 1; size > field.Len() {
+		field.Set(reflect.AppendSlice(field, reflect.MakeSlice(field.Type(), size-field.Len(), size-field.Len())))
+	}
+
+	for _, index := range sorted {
+		if err := l.setElem(ctx, prefix+strconv.Itoa(index), field.Index(index)); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (l Env) setMap(ctx context.Context, fieldName string, field reflect.Value) (bool, error) {
+	prefix := fieldName + "_"
+	elemType := field.Type().Elem()
+
+	var leafNames []string
+	if isStructElem(elemType) {
+		leafNames = envLeafNames(elemType)
+	}
+
+	keys := map[string]struct{}{}
+
+	for _, name := range l.namesWithPrefix(prefix) {
+		key := mapKey(strings.TrimPrefix(name, prefix), leafNames, isStructElem(elemType))
+		if key == "" && isStructElem(elemType) {
+			// whole value is given like NAME_<key>='{"url":"..."}'
+			if val, ok := l.lookup(name); ok && strings.HasPrefix(strings.TrimSpace(val), "{") {
+				key = strings.TrimPrefix(name, prefix)
+			}
+		}
+
+		if key != "" {
+			keys[key] = struct{}{}
+		}
+	}
+
+	if len(keys) == 0 {
+		return false, nil
+	}
+
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+
+	for key := range keys {
+		mapKey := reflect.ValueOf(key).Convert(field.Type().Key())
+
+		// map values are not addressable, so set a copy and put it back
+		elem := reflect.New(elemType).Elem()
+		if existing := field.MapIndex(mapKey); existing.IsValid() {
+			elem.Set(existing)
+		}
+
+		if err := l.setElem(ctx, prefix+key, elem); err != nil {
+			return false, err
+		}
+
+		field.SetMapIndex(mapKey, elem)
+	}
+
+	return true, nil
+}
+
+// setElem sets a slice or map element, struct elements are iterated with same functions.
+func (l Env) setElem(ctx context.Context, name string, elem reflect.Value) error {
+	if elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			elem.Set(reflect.New(elem.Type().Elem()))
+		}
+
+		elem = elem.Elem()
+	}
+
+	if !internal.IsStruct(elem.Type()) {
+		return l.setField(ctx, name, elem)
+	}
+
+	if err := l.setStruct(ctx, name, elem); err != nil {
+		return err
+	}
+
+	return l.iterate(ctx, internal.StructIterator{ReflectValue: elem.Addr(), BaseName: name})
+}
+
+// namesWithPrefix returns environment variable names starting with prefix.
+//
+// If PrefixFallback is enabled and nothing found, unprefixed names are returned.
+func (l Env) namesWithPrefix(prefix string) []string {
+	names := l.envNamesWithPrefix(prefix)
+	if len(names) > 0 || l.Prefix == "" || !l.PrefixFallback {
+		return names
+	}
+
+	envPrefix := strings.TrimSuffix(strings.ToUpper(l.Prefix), "_") + "_"
+	if !strings.HasPrefix(prefix, envPrefix) {
+		return nil
+	}
+
+	// return prefixed names to use same lookup in the iterator
+	for _, name := range l.envNamesWithPrefix(strings.TrimPrefix(prefix, envPrefix)) {
+		names = append(names, envPrefix+name)
+	}
+
+	return names
+}
+
+func (l Env) envNamesWithPrefix(prefix string) []string {
+	var names []string
+
+	for _, env := range l.source().Environ() {
+		name := env
+		if i := strings.Index(env, "="); i != -1 {
+			name = env[:i]
+		}
+
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// mapKey finds the key part in the rest of the variable name.
+//
+// For struct values, rest is '<key>_<leaf>' and key can contain underscores.
+func mapKey(rest string, leafNames []string, isStruct bool) string {
+	if !isStruct {
+		return rest
+	}
+
+	for _, leaf := range leafNames {
+		if strings.HasSuffix(rest, "_"+leaf) {
+			return strings.TrimSuffix(rest, "_"+leaf)
+		}
+
+		// leaf is a collection, like <key>_<leaf>_0_FIELD
+		if i := strings.LastIndex(rest, "_"+leaf+"_"); i > 0 {
+			return rest[:i]
+		}
+	}
+
+	return ""
+}
+
+func isStructElem(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return internal.IsStruct(typ)
+}
+
+// envLeafNames returns variable names of the fields in the struct type without any prefix.
+func envLeafNames(typ reflect.Type) []string {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	var names []string
+
+	it := internal.StructIterator{
+		ReflectValue:  reflect.New(typ),
+		FieldNameFunc: Env{}.FieldNameFunc,
+		IteratorFunc: func(fieldName string, _ reflect.Value) error {
+			names = append(names, fieldName)
+
+			return nil
+		},
+	}
+
+	_ = it.Iterate()
+
+	// longer names first to match the most specific one
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	return names
+}
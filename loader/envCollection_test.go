@@ -0,0 +1,100 @@
+package loader_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+type envBroker struct {
+	Host string
+	Port int
+	Tags []string
+}
+
+type envEndpoint struct {
+	URL     string
+	Timeout int `env:"TIMEOUT_MS"`
+}
+
+type envCollectionConfig struct {
+	Kafka struct {
+		Brokers []envBroker
+	}
+	Endpoints map[string]envEndpoint
+	Pointers  []*envBroker
+	Labels    map[string]string
+	Ports     []int
+}
+
+func TestEnv_Collections(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS_0_HOST", "kafka-0")
+	t.Setenv("KAFKA_BROKERS_0_PORT", "9092")
+	t.Setenv("KAFKA_BROKERS_2_HOST", "kafka-2")
+	t.Setenv("KAFKA_BROKERS_2_TAGS", "a,b")
+	t.Setenv("ENDPOINTS_billing_URL", "http://billing")
+	t.Setenv("ENDPOINTS_user_api_URL", "http://user")
+	t.Setenv("ENDPOINTS_user_api_TIMEOUT_MS", "500")
+	t.Setenv("POINTERS_1_HOST", "pointer")
+	t.Setenv("LABELS_team", "core")
+	t.Setenv("PORTS_0", "80")
+	t.Setenv("PORTS_1", "443")
+
+	var c envCollectionConfig
+
+	c.Kafka.Brokers = []envBroker{{Host: "old", Port: 1}}
+
+	require.NoError(t, loader.Env{}.Load("", &c))
+
+	// index is the position, gaps are zero elements
+	assert.Equal(t, []envBroker{
+		{Host: "kafka-0", Port: 9092},
+		{},
+		{Host: "kafka-2", Tags: []string{"a", "b"}},
+	}, c.Kafka.Brokers)
+	assert.Equal(t, map[string]envEndpoint{
+		"billing":  {URL: "http://billing"},
+		"user_api": {URL: "http://user", Timeout: 500},
+	}, c.Endpoints)
+	assert.Equal(t, []*envBroker{nil, {Host: "pointer"}}, c.Pointers)
+	assert.Equal(t, map[string]string{"team": "core"}, c.Labels)
+	assert.Equal(t, []int{80, 443}, c.Ports)
+}
+
+func TestEnv_CollectionsPrefix(t *testing.T) {
+	t.Setenv("APP_ENDPOINTS_billing_URL", "http://billing")
+	t.Setenv("PORTS_0", "8080")
+
+	var c envCollectionConfig
+
+	require.NoError(t, loader.Env{Prefix: "APP", PrefixFallback: true}.Load("", &c))
+
+	assert.Equal(t, map[string]envEndpoint{"billing": {URL: "http://billing"}}, c.Endpoints)
+	assert.Equal(t, []int{8080}, c.Ports)
+}
+
+func TestEnv_CollectionsIndex(t *testing.T) {
+	var c envCollectionConfig
+
+	c.Ports = []int{1, 2}
+
+	require.NoError(t, loader.Env{Source: loader.EnvMap{
+		"PORTS_1": "443",
+		"PORTS_4": "8443",
+	}}.Load("", &c))
+
+	assert.Equal(t, []int{1, 443, 0, 0, 8443}, c.Ports)
+
+	// same variable is in the same position when the slice is shorter
+	c.Ports = nil
+
+	require.NoError(t, loader.Env{Source: loader.EnvMap{"PORTS_4": "8443"}}.Load("", &c))
+	assert.Equal(t, []int{0, 0, 0, 0, 8443}, c.Ports)
+
+	err := loader.Env{Source: loader.EnvMap{"PORTS_999999999": "8080"}}.Load("", &c)
+	assert.ErrorIs(t, err, loader.ErrSliceIndex)
+	assert.ErrorContains(t, err, "PORTS_999999999")
+}
@@ -0,0 +1,33 @@
+package loader
+
+import (
+	"context"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// EnvSource is a source of environment variables, see Env.Source and WithEnvSource.
+type EnvSource = internal.EnvSource
+
+// OSEnv is the environment of the process, it is the default source.
+type OSEnv = internal.OSEnv
+
+// EnvMap is a static map of variables, useful in tests.
+type EnvMap = internal.EnvMap
+
+// EnvFunc is a lookup function as a source.
+// Slice and map fields cannot be discovered with indexed names since variables cannot be listed.
+type EnvFunc = internal.EnvFunc
+
+// EnvSnapshot returns a copy of the current environment of the process.
+func EnvSnapshot() EnvMap {
+	return internal.EnvSnapshot()
+}
+
+// WithEnvSource returns a context to read environment variables from source.
+//
+// Loaders use the source in the context for their variables, like Consul path prefix and Vault settings.
+// NewVaulterFromClient also reads Vault variables from this source.
+func WithEnvSource(ctx context.Context, source EnvSource) context.Context {
+	return internal.WithEnvSource(ctx, source)
+}
@@ -0,0 +1,106 @@
+package loader_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	vault "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+	"github.com/worldline-go/igconfig/testdata"
+)
+
+func TestEnv_Source(t *testing.T) {
+	t.Parallel()
+
+	source := loader.EnvMap{
+		"NAME":         "source",
+		"APP_PORT":     "8080",
+		"SLICE":        "1,2",
+		"ENDPOINTS_a":  "x",
+		"NOT_IN_FIELD": "y",
+	}
+
+	var c testdata.TestConfig
+
+	require.NoError(t, loader.Env{Source: source}.Load("", &c))
+	assert.Equal(t, "source", c.Name)
+	assert.Equal(t, []string{"1", "2"}, c.Slice)
+
+	var m struct {
+		Port      int
+		Endpoints map[string]string
+	}
+
+	ctx := loader.WithEnvSource(context.Background(), source)
+
+	require.NoError(t, loader.Env{Prefix: "APP", PrefixFallback: true}.LoadWithContext(ctx, "", &m))
+	assert.Equal(t, 8080, m.Port)
+	assert.Equal(t, map[string]string{"a": "x"}, m.Endpoints)
+
+	fn := loader.EnvFunc(func(key string) (string, bool) {
+		return "func", key == "APP_NAME"
+	})
+
+	require.NoError(t, loader.Env{Source: fn, Prefix: "APP"}.Load("", &c))
+	assert.Equal(t, "func", c.Name)
+}
+
+func TestConsul_EnvSource(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/custom/app", r.URL.Path)
+		assert.Equal(t, "consul-token", r.Header.Get("X-Consul-Token"))
+
+		_ = json.NewEncoder(w).Encode([]*api.KVPair{{Key: "custom/app", Value: []byte(`settle_name: consul`)}})
+	}))
+	defer server.Close()
+
+	ctx := loader.WithEnvSource(context.Background(), loader.EnvMap{
+		api.HTTPAddrEnvName:              server.URL,
+		api.HTTPTokenEnvName:             "consul-token",
+		loader.ConsulConfigPathPrefixEnv: "custom",
+	})
+
+	var c testdata.TestConfig
+
+	require.NoError(t, loader.Consul{}.LoadWithContext(ctx, "app", &c))
+	assert.Equal(t, "consul", c.Name)
+
+	_, err := loader.NewConsulFromEnvSource(loader.EnvMap{})
+	assert.ErrorIs(t, err, loader.ErrNoClient)
+}
+
+func TestNewVaulterFromClient_EnvSource(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/auth/custom/login", r.URL.Path)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "role-token"},
+		})
+	}))
+	defer server.Close()
+
+	ctx := loader.WithEnvSource(context.Background(), loader.EnvMap{
+		"VAULT_ADDR":                   server.URL,
+		"VAULT_CONSUL_ADDR_DISABLE":    "true",
+		loader.VaultRoleIDEnv:          "role",
+		loader.VaultAppRoleBasePathEnv: "auth/custom/login",
+	})
+
+	cl, err := vault.NewClient(&vault.Config{Address: server.URL})
+	require.NoError(t, err)
+
+	_, err = loader.NewVaulterFromClient(ctx, cl)
+	require.NoError(t, err)
+	assert.Equal(t, "role-token", cl.Token())
+}
@@ -1,8 +1,12 @@
 package loader_test
 
 import (
+	"bytes"
+	"context"
+	"path/filepath"
 	"testing"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -40,3 +44,124 @@ func TestEnvValues(t *testing.T) {
 		},
 	}, c)
 }
+
+func TestEnv_Prefix(t *testing.T) {
+	t.Setenv("PAYMENTS_WORKER_NAME", "prefixed")
+	t.Setenv("PAYMENTS_WORKER_INNERSTRUCT_STRING", "inner")
+	t.Setenv("PORT", "9090")
+
+	var c testdata.TestConfig
+
+	require.NoError(t, (loader.Env{AppNamePrefix: true}).Load("payments/worker", &c))
+
+	assert.Equal(t, "prefixed", c.Name)
+	assert.Equal(t, "inner", c.InnerStruct.Str)
+	assert.Equal(t, 0, c.Port)
+
+	require.NoError(t, (loader.Env{Prefix: "payments_worker_", PrefixFallback: true}).Load("", &c))
+
+	assert.Equal(t, "prefixed", c.Name)
+	assert.Equal(t, 9090, c.Port)
+}
+
+func TestEnvPrefix(t *testing.T) {
+	assert.Equal(t, "PAYMENTS_SETTLEMENT_WORKER", loader.EnvPrefix("/payments/settlement-worker/"))
+	assert.Equal(t, "APP", loader.EnvPrefix("app"))
+}
+
+func TestEnv_File(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"secrets/name": "secret-name\n",
+		"other/port":   "8080",
+	})
+
+	t.Setenv("NAME_FILE", filepath.Join(dir, "secrets/name"))
+	t.Setenv("PORT_FILE", filepath.Join(dir, "other/port"))
+	t.Setenv("HOST", "direct")
+	t.Setenv("HOST_FILE", filepath.Join(dir, "secrets/name"))
+
+	var c testdata.TestConfig
+
+	require.NoError(t, (loader.Env{}).Load("", &c))
+
+	assert.Equal(t, "secret-name", c.Name)
+	assert.Equal(t, 8080, c.Port)
+	assert.Equal(t, "direct", c.Host)
+
+	c = testdata.TestConfig{}
+	err := (loader.Env{FileDirs: []string{filepath.Join(dir, "secrets")}}).Load("", &c)
+	assert.ErrorIs(t, err, loader.ErrFileNotAllowed)
+
+	c = testdata.TestConfig{}
+	require.NoError(t, (loader.Env{NoFileSuffix: true}).Load("", &c))
+	assert.Equal(t, "", c.Name)
+
+	c = testdata.TestConfig{}
+	require.NoError(t, (loader.Env{FileSuffix: "_PATH", Source: loader.EnvMap{
+		"NAME_PATH": filepath.Join(dir, "secrets/name"),
+		"PORT_FILE": filepath.Join(dir, "other/port"),
+	}}).Load("", &c))
+	assert.Equal(t, "secret-name", c.Name)
+	assert.Equal(t, 0, c.Port)
+}
+
+func TestEnv_FileSkip(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"token": "secret"})
+
+	var c struct {
+		Log     string
+		Token   string
+		Cert    string
+		CertDir string `env:"CERT_FILE"`
+		Files   map[string]string
+	}
+
+	require.NoError(t, (loader.Env{Source: loader.EnvMap{
+		"LOG_FILE":     filepath.Join(dir, "app.log"),
+		"TOKEN_FILE":   filepath.Join(dir, "token"),
+		"CERT_FILE":    "/etc/certs",
+		"FILES_a_FILE": "x",
+	}}).Load("", &c))
+
+	// missing file is skipped
+	assert.Equal(t, "", c.Log)
+	assert.Equal(t, "secret", c.Token)
+	// CERT_FILE is the name of another field
+	assert.Equal(t, "", c.Cert)
+	assert.Equal(t, "/etc/certs", c.CertDir)
+	assert.Equal(t, map[string]string{"a_FILE": "x"}, c.Files)
+}
+
+func TestEnv_Aliases(t *testing.T) {
+	type config struct {
+		URL  string `env:"DB_URL,DATABASE_URL!deprecated"`
+		Port int    `env:"PORT,LISTEN_PORT"`
+		DB   struct {
+			User string
+		} `env:"DB,DATABASE!deprecated"`
+	}
+
+	t.Setenv("DATABASE_URL", "old-url")
+	t.Setenv("PORT", "8080")
+	t.Setenv("LISTEN_PORT", "9090")
+	t.Setenv("DATABASE_USER", "old-user")
+
+	var buf bytes.Buffer
+
+	ctx := zerolog.New(&buf).WithContext(context.Background())
+
+	var c config
+
+	require.NoError(t, loader.Env{}.LoadWithContext(ctx, "", &c))
+
+	assert.Equal(t, "old-url", c.URL)
+	assert.Equal(t, 8080, c.Port)
+	assert.Equal(t, "old-user", c.DB.User)
+	assert.Contains(t, buf.String(), `"env":"DATABASE_URL","use":"DB_URL"`)
+	assert.Contains(t, buf.String(), `"env":"DATABASE_USER","use":"DB_USER"`)
+
+	t.Setenv("DB_URL", "new-url")
+
+	require.NoError(t, loader.Env{}.Load("", &c))
+	assert.Equal(t, "new-url", c.URL)
+}
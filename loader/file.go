@@ -4,13 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/worldline-go/igconfig/codec"
+	"github.com/worldline-go/igconfig/internal"
 )
 
 // FileTag is a tag name for file loader
@@ -18,14 +18,16 @@ var FileTag = "cfg"
 
 // ConfFileSuffixes is the ordered list of suffix for configuration file.
 // It is not specific for type(.toml .yml, .yaml, .json) because it is possible to change which loader will be used.
-var ConfFileSuffixes = []string{".toml", ".yml", ".yaml", ".json"}
+var ConfFileSuffixes = []string{".toml", ".yml", ".yaml", ".json", ".jsonc", ".json5"}
 
 // FileDecoders for file extensions
 var FileDecoders = map[string]codec.Decoder{
-	".toml": codec.TOML{},
-	".yml":  codec.YAML{},
-	".yaml": codec.YAML{},
-	".json": codec.JSON{},
+	".toml":  codec.TOML{},
+	".yml":   codec.YAML{},
+	".yaml":  codec.YAML{},
+	".json":  codec.JSON{},
+	".jsonc": codec.JSON{Comments: true},
+	".json5": codec.JSON5{},
 }
 
 // ErrNoDecoder is a serious error and not continue process.
@@ -44,7 +46,7 @@ const EnvConfigFile = "CONFIG_FILE"
 
 // File is intended to be a limited time option to read configuration from files.
 // Set configuration path on CONFIG_FILE environment variable.
-// '.yml|.yaml|.json' extensions supported.
+// '.toml|.yml|.yaml|.json|.jsonc|.json5' extensions supported.
 //
 // Breaking changes from v1: config field name will be used as-is, without changing case.
 type File struct {
@@ -62,9 +64,9 @@ type File struct {
 //
 // Not existing configuration files are not treated as an error.
 // If this behavior is required - use `Reader.Load*` methods directly.
-func (l File) LoadWithContext(_ context.Context, appName string, to interface{}) error {
+func (l File) LoadWithContext(ctx context.Context, appName string, to interface{}) error {
 	// check ENV file
-	err := l.LoadEnv(to)
+	err := l.loadEnv(ctx, to)
 	if !errors.Is(err, ErrNoEnv) {
 		return err
 	}
@@ -74,13 +76,13 @@ func (l File) LoadWithContext(_ context.Context, appName string, to interface{})
 	}
 
 	// check working directory
-	err = l.LoadWorkDir(appName, to)
+	err = l.loadFileSuffix(ctx, cleanName(appName), to)
 	if !errors.Is(err, ErrNoConfFile) {
 		return err
 	}
 
 	// check etc directory
-	err = l.LoadEtc(appName, to)
+	err = l.loadEtc(ctx, appName, to)
 	if !errors.Is(err, ErrNoConfFile) {
 		return err
 	}
@@ -106,6 +108,10 @@ func (l File) LoadWorkDir(appName string, to interface{}) error {
 // LoadEtc will load configuration file from /etc directory.
 // File name is appName, so resulting path will be /etc/<appName>.
 func (l *File) LoadEtc(appName string, to interface{}) error {
+	return l.loadEtc(context.Background(), appName, to)
+}
+
+func (l *File) loadEtc(ctx context.Context, appName string, to interface{}) error {
 	appName = cleanName(appName)
 
 	if l.EtcPath == "" {
@@ -114,15 +120,19 @@ func (l *File) LoadEtc(appName string, to interface{}) error {
 
 	filePath := path.Join(l.EtcPath, appName)
 
-	return l.LoadFileSuffix(filePath, to)
+	return l.loadFileSuffix(ctx, filePath, to)
 }
 
 // LoadFileSuffix will load configuration from file path.
 func (l File) LoadFileSuffix(filePath string, to interface{}) error {
+	return l.loadFileSuffix(context.Background(), filePath, to)
+}
+
+func (l File) loadFileSuffix(ctx context.Context, filePath string, to interface{}) error {
 	for _, s := range ConfFileSuffixes {
 		if _, err := os.Stat(filePath + s); !os.IsNotExist(err) {
 			// suffix = s
-			return l.LoadFile(filePath+s, to)
+			return l.loadFile(ctx, filePath+s, to)
 		}
 	}
 
@@ -131,35 +141,42 @@ func (l File) LoadFileSuffix(filePath string, to interface{}) error {
 
 // LoadEnv will load CONFIG_FILE environment variable.
 func (l File) LoadEnv(to interface{}) error {
-	if envFile := os.Getenv(EnvConfigFile); envFile != "" {
-		return l.LoadFile(envFile, to)
+	return l.loadEnv(context.Background(), to)
+}
+
+func (l File) loadEnv(ctx context.Context, to interface{}) error {
+	if envFile := internal.GetEnvWithFallback(internal.EnvSourceFromContext(ctx), EnvConfigFile, ""); envFile != "" {
+		return l.loadFile(ctx, envFile, to)
 	}
 
 	return ErrNoEnv
 }
 
 // LoadFile loads config values from a fileName.
+//
+// File can include other files with FileIncludeKey, FileExtendsKey or YAML FileIncludeYAMLTag.
+// See readFile for details.
 func (l File) LoadFile(fileName string, to interface{}) error {
-	file, err := os.Open(fileName)
+	return l.loadFile(context.Background(), fileName, to)
+}
+
+func (l File) loadFile(ctx context.Context, fileName string, to interface{}) error {
+	mapping, err := l.readFile(ctx, fileName, nil)
 	if err != nil {
-		return fmt.Errorf("file loader: %w", err)
+		return err
 	}
-	defer file.Close() // nolint: errcheck
 
-	return l.loadReader(file, to, filepath.Ext(fileName))
-}
+	decoder := FileDecoders[filepath.Ext(fileName)]
 
-// loadReader automatically choice decoder with config file suffix.
-func (l File) loadReader(reader io.Reader, to interface{}, configType string) error {
-	var decoder codec.Decoder
-
-	decoder, ok := FileDecoders[configType]
-	if !ok {
-		return fmt.Errorf("%w: %s", ErrNoDecoder, configType)
-	}
+	if err := codec.MapDecoderStrict(ctx, &mapping, to, FileTag, fileName, codec.DecoderStrictMode(decoder)); err != nil {
+		var unknownErr *codec.UnknownKeysError
+		if !errors.As(err, &unknownErr) {
+			// position is searched only in the main file, included files are not checked.
+			data, _ := os.ReadFile(fileName)
+			err = codec.NewDecodeError(fileName, data, decoder, err)
+		}
 
-	if err := codec.LoadReaderWithDecoder(reader, to, decoder, FileTag); err != nil {
-		return fmt.Errorf("File.loadReader error: %w", err)
+		return fmt.Errorf("File.LoadFile error: %w", err)
 	}
 
 	return nil
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/worldline-go/igconfig/codec"
 	"github.com/worldline-go/igconfig/loader"
 	"github.com/worldline-go/igconfig/testdata"
 )
@@ -217,3 +218,20 @@ innerstruct:
 		InnerStruct: testdata.UntaggedInnerStruct{Str: "test_me"},
 	}, c)
 }
+
+func TestFile_LoadFile_DecodeError(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml": "name: test\nport: abc",
+	})
+
+	fileName := filepath.Join(dir, "app.yaml")
+
+	err := loader.File{}.LoadFile(fileName, &testdata.TestConfig{})
+
+	var decodeErr *codec.DecodeError
+	require.True(t, errors.As(err, &decodeErr), err)
+
+	assert.Equal(t, fileName, decodeErr.Source)
+	assert.Equal(t, "port", decodeErr.Path)
+	assert.Equal(t, codec.Position{Line: 2, Column: 1}, decodeErr.Position)
+}
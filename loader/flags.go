@@ -2,12 +2,16 @@ package loader
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strings"
 
+	"github.com/rs/zerolog/log"
+
 	"github.com/worldline-go/igconfig/internal"
 )
 
@@ -16,6 +20,9 @@ var _ Loader = Flags{}
 // CmdTag is the tag used to specify a command line flag.
 const CmdTag = "cmd"
 
+// ErrFlagConflict is returned when same flag name is used for multiple fields.
+var ErrFlagConflict = errors.New("flag name conflict")
+
 // Flags will parse CMD args.
 //
 // Flags defined in inner structs will be set with
@@ -37,88 +44,335 @@ const CmdTag = "cmd"
 //
 // Breaking change from v1: parsing will fail with usage if unknown flag will be found.
 //
-// Also only first value from the tag will be used.
-// This means that if tag is 'cmd:"tag,t"' - only "tag" will be used as command line.
-// This might be changed in the future.
+// All values from the tag are flag names, if tag is 'cmd:"tag,t"' - both "-tag" and "-t" can be used.
+// One letter names are not prefixed with outer names.
+// Names with "!deprecated" suffix log a warning when used, like 'cmd:"port,listen!deprecated"'.
+// Same name in multiple fields returns ErrFlagConflict, also for one letter names.
+// "-h" is always help, a field with "h" name returns ErrFlagConflict.
+//
+// Usage text of the flags is read from FlagUsageTags, like 'usage:"port to listen"'.
+// Help is printed with -h or --help and *HelpError is returned.
+//
+// Positional arguments left after parsing are set to the fields with ArgTag, like 'arg:"0,required"' or 'arg:"rest"'.
+//
+// Direct struct fields with 'cmd:"name,subcommand"' are subcommands, first positional argument selects one.
+// Rest of the arguments are loaded to that struct with its own flags, see Command.
+//
+// Hidden flag '--completion <shell>' writes the completion script of the flags and *CompletionError is returned.
 type Flags struct {
 	// Args to give manually read from flags default os.Args[1:]
 	Args []string
-	// NoUsage may be used to silence usage on invalid flags.
+	// NoUsage may be used to silence usage on invalid flags and help request.
+	// Help text is still available in the returned *HelpError.
 	NoUsage bool
+	// Output for usage and errors, default is os.Stderr.
+	Output io.Writer
+	// GNU enables GNU style parsing: bundled short flags like "-abc" or "-p8080",
+	// "--no-feature" for boolean flags and flags after positional arguments.
+	GNU bool
+	// Lenient keeps unknown flags in Rest instead of returning an error, they are not positional arguments.
+	// Flags after positional arguments are also parsed in this mode.
+	Lenient bool
+	// Rest is set to the positional arguments and the unknown flags in Lenient mode, if it is not nil.
+	// Arguments after "--" are always in Rest.
+	Rest *[]string
+	// Command is set to the chosen subcommand, nested subcommands are separated by space like "db migrate".
+	Command *string
+
+	// appName is shown in usage.
+	appName string
+	// names holds all names of the fields with the first name as key.
+	names map[string][]internal.FieldName
+	// usage holds information of the fields for help text.
+	usage *flagUsage
+	// commands are the subcommands of the struct.
+	commands []flagCommand
+	// envPrefix is the env name of the subcommand field, it is shown in usage.
+	envPrefix string
+	// root and rootAppName are the config and the name of the main command, completion script is written for them.
+	root        interface{}
+	rootAppName string
 }
 
 // LoadWithContext loads config values from the command line with context.
-// Context value not used in this loader.
-func (l Flags) LoadWithContext(_ context.Context, _ string, to interface{}) error {
+// Context is used for logging.
+func (l Flags) LoadWithContext(ctx context.Context, appName string, to interface{}) error {
 	if l.Args == nil && len(os.Args) >= 1 {
 		l.Args = os.Args[1:]
 	}
 
-	return l.LoadSlice(to, l.Args)
+	l.appName = appName
+
+	return l.loadSlice(ctx, to, l.Args)
 }
 
 // Load loads config values from the command line.
-func (l Flags) Load(_ string, to interface{}) error {
-	return l.LoadWithContext(context.TODO(), "", to)
+func (l Flags) Load(appName string, to interface{}) error {
+	return l.LoadWithContext(context.TODO(), appName, to)
 }
 
 // LoadSlice loads config values from the command line.
 func (l Flags) LoadSlice(to interface{}, args []string) error {
+	return l.loadSlice(context.Background(), to, args)
+}
+
+func (l Flags) loadSlice(ctx context.Context, to interface{}, args []string) error {
+	if l.Rest != nil {
+		*l.Rest = nil
+	}
+
+	if l.Command != nil {
+		*l.Command = ""
+	}
+
 	if len(args) == 0 {
-		return nil
+		// required positional arguments are checked
+		return l.setPositional(to, nil)
 	}
 
 	flags := flag.FlagSet{}
 
-	if l.NoUsage {
-		flags.Usage = func() {}
+	if l.Output != nil {
+		flags.SetOutput(l.Output)
 	}
 
-	it := internal.StructIterator{
-		Value:         to,
-		FieldNameFunc: internal.FieldNameWithSeparator(CmdTag, "-", strings.ToLower),
-		IteratorFunc:  l.AddFlagsIterator(&flags),
+	l.names = make(map[string][]internal.FieldName)
+	l.usage = newFlagUsage()
+	l.commands = subcommands(to)
+	l.usage.commands = l.commands
+	l.usage.envPrefix = l.envPrefix
+
+	// tag errors are returned when positional arguments are set
+	if positional, err := positionalArgs(to); err == nil {
+		l.usage.args = positionalUsage(positional)
 	}
 
-	if err := it.Iterate(); err != nil {
+	flags.Usage = func() {
+		if !l.NoUsage {
+			l.usage.Render(flags.Output(), l.appName)
+		}
+	}
+
+	it, err := l.addFlags(ctx, &flags, to)
+	if err != nil {
 		return err
 	}
 
+	var completion *string
+	if CompletionFlag != "" && flags.Lookup(CompletionFlag) == nil {
+		completion = flags.String(CompletionFlag, "", "")
+	}
+
+	var rest, positional []string
+	if l.GNU || l.Lenient {
+		args, rest, positional = l.normalizeArgs(&flags, args)
+	}
+
 	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			var usage strings.Builder
+			l.usage.Render(&usage, l.appName)
+
+			return &HelpError{Usage: usage.String()}
+		}
+
 		return fmt.Errorf("flags parsing error: %s", err.Error())
 	}
 
-	it.IteratorFunc = l.ProcessFlagsIterator(flags)
+	if completion != nil && *completion != "" {
+		return l.writeCompletion(ctx, *completion, to)
+	}
+
+	rest = append(rest, flags.Args()...)
+	positional = append(positional, flags.Args()...)
+
+	// first positional argument selects the subcommand, unknown flags are only in rest
+	var command *flagCommand
+
+	if len(positional) > 0 {
+		if c, ok := findCommand(l.commands, positional[0]); ok {
+			command = &c
+		}
+	}
+
+	if l.Rest != nil && command == nil {
+		*l.Rest = rest
+	}
+
+	it.IteratorFunc = l.processFlagsIterator(ctx, flags)
+	it.StructFunc = nil
+
+	if err := it.Iterate(); err != nil {
+		return err
+	}
+
+	// positional arguments of the parent are not checked when a subcommand is chosen
+	if command != nil {
+		return l.loadCommand(ctx, to, *command, positional[1:])
+	}
+
+	return l.setPositional(to, positional)
+}
+
+// addFlags adds flags of all fields to the flag set, returned iterator is used to process the flags.
+func (l Flags) addFlags(ctx context.Context, flags *flag.FlagSet, to interface{}) (internal.StructIterator, error) {
+	it := internal.StructIterator{
+		Value:         to,
+		FieldNameFunc: l.FieldNameFunc,
+		IteratorFunc:  l.addFlagsIterator(ctx, flags),
+		// struct fields can be set with JSON values
+		StructFunc: l.addFlagsIterator(ctx, flags),
+	}
 
-	return it.Iterate()
+	return it, it.Iterate()
 }
 
 // FieldNameFunc returns a field name retrieved from `cmd` tag,
 // concatenated with '-'(minus sign) and lowercased.
+//
+// Only the first name is returned, others are used in iterators when called from LoadSlice.
+// Fields with ArgTag are positional arguments and subcommand fields are loaded separately, they are skipped.
 func (l Flags) FieldNameFunc(outer string, field reflect.StructField) string {
-	return internal.FieldNameWithSeparator(CmdTag, "-", strings.ToLower)(outer, field)
+	if _, ok := field.Tag.Lookup(ArgTag); ok || isSubcommand(field) {
+		return internal.SkipFieldTagValue
+	}
+
+	outerNames := l.names[outer]
+	if outerNames == nil && outer != "" {
+		outerNames = []internal.FieldName{{Name: outer}}
+	}
+
+	names := shortFlagNames(internal.FieldNamesWithSeparator(CmdTag, "-", outerNames, field, strings.ToLower), field)
+
+	// names are registered when flags are added, later iterations use the same names
+	if _, ok := l.names[names[0].Name]; !ok && l.names != nil {
+		l.names[names[0].Name] = names
+	}
+
+	if l.usage != nil {
+		l.usage.addField(outer, names, field)
+	}
+
+	return names[0].Name
+}
+
+// shortFlagNames keeps one letter names without outer names, so 'cmd:"port,p"' in a nested struct is still '-p'.
+//
+// First name is the field name, it is kept with outer names and the one letter name is added after it.
+func shortFlagNames(names []internal.FieldName, field reflect.StructField) []internal.FieldName {
+	shorts := make(map[string]bool)
+
+	for _, tagValue := range internal.TagValueByKeys(field.Tag, CmdTag) {
+		short := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(tagValue), internal.DeprecatedMarker))
+		if len(short) == 1 {
+			shorts[short] = true
+		}
+	}
+
+	result := make([]internal.FieldName, 0, len(names)+1)
+	// remove duplicates created by outer names
+	seen := make(map[string]bool, len(names))
+
+	for i, name := range names {
+		if sep := strings.LastIndex(name.Name, "-"); sep != -1 && shorts[name.Name[sep+1:]] {
+			if i == 0 {
+				seen[name.Name] = true
+
+				result = append(result, name)
+			}
+
+			name.Name = name.Name[sep+1:]
+		}
+
+		if !seen[name.Name] {
+			seen[name.Name] = true
+
+			result = append(result, name)
+		}
+	}
+
+	return result
 }
 
 // AddFlagsIterator is the function to add flags to a specified flag set.
+//
+// A flag is added for every name of the field,
+// ErrFlagConflict is returned if a name is already used by another field or it is "h".
 func (l Flags) AddFlagsIterator(set *flag.FlagSet) internal.IteratorFunc {
+	return l.addFlagsIterator(context.Background(), set)
+}
+
+func (l Flags) addFlagsIterator(ctx context.Context, set *flag.FlagSet) internal.IteratorFunc {
 	return func(fieldName string, field reflect.Value) error {
-		setFlagForKind(set, field.Type().Kind(), fieldName, field)
+		names := l.fieldNames(fieldName)
+
+		// names of a slice or map field share the value, so values of all names are appended
+		var repeated *repeatedFlagVar
+		if kind := field.Kind(); kind == reflect.Slice || kind == reflect.Map {
+			repeated = &repeatedFlagVar{name: fieldName, val: field}
+		}
+
+		for _, name := range names {
+			if name.Name == "h" {
+				return fmt.Errorf("%w: -h of %q is the help flag", ErrFlagConflict, fieldName)
+			}
+
+			if set.Lookup(name.Name) != nil {
+				return fmt.Errorf("%w: -%s of %q is already defined", ErrFlagConflict, name.Name, fieldName)
+			}
+
+			usage := l.usage.fieldUsage(fieldName)
+			if name.Deprecated {
+				usage = fmt.Sprintf("deprecated, use -%s", fieldName)
+			}
+
+			setFlagForKind(set, field.Type().Kind(), name.Name, field, usage, repeated)
+		}
+
+		if l.usage != nil {
+			l.usage.setValue(fieldName, field, set.Lookup(names[0].Name))
+		}
 
 		return nil
 	}
 }
 
 // ProcessFlagsIterator is the function to set flag values based on already parsed flags.
+//
+// Value is taken from the first name of the field that is set in arguments,
+// a warning is logged if it is a deprecated name. Fields without flags in arguments are not changed.
 func (l Flags) ProcessFlagsIterator(set flag.FlagSet) internal.IteratorFunc {
+	return l.processFlagsIterator(context.Background(), set)
+}
+
+func (l Flags) processFlagsIterator(ctx context.Context, set flag.FlagSet) internal.IteratorFunc {
+	visited := make(map[string]bool)
+	set.Visit(func(fl *flag.Flag) {
+		visited[fl.Name] = true
+	})
+
 	return func(fieldName string, field reflect.Value) error {
-		// Flag will always be defined, as guaranteed by previous iteration.
-		fl := set.Lookup(fieldName)
+		var name *internal.FieldName
 
-		// don't check nil values
-		if fl == nil {
+		for _, n := range l.fieldNames(fieldName) {
+			if visited[n.Name] {
+				n := n
+				name = &n
+
+				warnDeprecatedFlag(ctx, n, fieldName)
+
+				break
+			}
+		}
+
+		// Flags not in arguments keep the field value,
+		// also values of struct flags should not be overwritten by inner flag defaults.
+		if name == nil {
 			return nil
 		}
 
+		fl := set.Lookup(name.Name)
+
 		if _, ok := fl.Value.(flag.Getter).Get().(reflect.Value); ok {
 			// If value is reflect.Value then it should not be set:
 			// it was already set when the flags were parsed.
@@ -129,24 +383,70 @@ func (l Flags) ProcessFlagsIterator(set flag.FlagSet) internal.IteratorFunc {
 	}
 }
 
-func setFlagForKind(flags *flag.FlagSet, fieldKind reflect.Kind, flagName string, defValue reflect.Value) {
-	if setter := internal.GetCustomSetter(defValue.Type()); setter != nil {
-		flags.Var(CustomFlagVar{Setter: setter, Val: defValue}, flagName, "")
+func (l Flags) fieldNames(fieldName string) []internal.FieldName {
+	if names, ok := l.names[fieldName]; ok {
+		return names
+	}
+
+	return []internal.FieldName{{Name: fieldName}}
+}
+
+func warnDeprecatedFlag(ctx context.Context, name internal.FieldName, fieldName string) {
+	if !name.Deprecated {
+		return
+	}
+
+	log.Ctx(ctx).Warn().Str("flag", name.Name).Str("use", fieldName).Msg("deprecated flag")
+}
+
+// setFlagForKind adds a flag for the field.
+//
+// Custom types, structs, maps and slices are set with setValueString, so they accept JSON or YAML flow values.
+// Slice and map flags can be repeated, like '--tag a --tag b' or '--label a=1 --label b=2',
+// repeated is the value of all names of the field.
+func setFlagForKind(flags *flag.FlagSet, fieldKind reflect.Kind, flagName string, defValue reflect.Value, usage string, repeated *repeatedFlagVar) {
+	// embedded structs without names
+	if isDecodeKind(fieldKind) && (flagName == "" || strings.HasSuffix(flagName, "-")) {
+		return
+	}
+
+	if repeated != nil {
+		flags.Var(repeated, flagName, usage)
+
+		return
+	}
+
+	// pointers are set only when flag is given
+	if internal.GetCustomSetter(defValue.Type()) != nil || isDecodeKind(fieldKind) || fieldKind == reflect.Ptr {
+		setter := func(input string, val reflect.Value) error {
+			return setValueString(flagName, input, val)
+		}
+
+		flags.Var(CustomFlagVar{Setter: setter, Val: defValue}, flagName, usage)
 
 		return
 	}
 
 	switch fieldKind {
 	case reflect.Bool:
-		flags.Bool(flagName, defValue.Bool(), "")
+		flags.Bool(flagName, defValue.Bool(), usage)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		flags.Int64(flagName, defValue.Int(), "")
+		flags.Int64(flagName, defValue.Int(), usage)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		flags.Uint64(flagName, defValue.Uint(), "")
+		flags.Uint64(flagName, defValue.Uint(), usage)
 	case reflect.Float32, reflect.Float64:
-		flags.Float64(flagName, defValue.Float(), "")
+		flags.Float64(flagName, defValue.Float(), usage)
 	case reflect.String:
-		flags.String(flagName, defValue.String(), "")
+		flags.String(flagName, defValue.String(), usage)
+	}
+}
+
+func isDecodeKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -161,7 +461,12 @@ func (c CustomFlagVar) String() string {
 		return ""
 	}
 
-	return fmt.Sprint(c.Val.Interface())
+	return fmt.Sprint(reflect.Indirect(c.Val).Interface())
+}
+
+// IsBoolFlag returns true for bool pointers, so they can be used without value like bool flags.
+func (c CustomFlagVar) IsBoolFlag() bool {
+	return c.Val.IsValid() && c.Val.Type().Kind() == reflect.Ptr && c.Val.Type().Elem().Kind() == reflect.Bool
 }
 
 //nolint:golint
@@ -177,3 +482,54 @@ func (c CustomFlagVar) Set(s string) error {
 func (c CustomFlagVar) Get() interface{} {
 	return c.Val
 }
+
+// repeatedFlagVar is a flag of slice or map field, values of repeated flags are appended.
+//
+// First value replaces the previous value of the field, like defaults.
+type repeatedFlagVar struct {
+	name string
+	val  reflect.Value
+	set  bool
+}
+
+func (r *repeatedFlagVar) String() string {
+	if r == nil || !r.val.IsValid() || r.val.IsZero() {
+		return ""
+	}
+
+	return fmt.Sprint(r.val.Interface())
+}
+
+func (r *repeatedFlagVar) Set(s string) error {
+	v := reflect.New(r.val.Type()).Elem()
+	if err := setValueString(r.name, s, v); err != nil {
+		return err
+	}
+
+	if !r.set {
+		r.val.Set(reflect.Zero(r.val.Type()))
+		r.set = true
+	}
+
+	if r.val.Kind() == reflect.Slice {
+		r.val.Set(reflect.AppendSlice(r.val, v))
+
+		return nil
+	}
+
+	if r.val.IsNil() {
+		r.val.Set(reflect.MakeMap(r.val.Type()))
+	}
+
+	iter := v.MapRange()
+	for iter.Next() {
+		r.val.SetMapIndex(iter.Key(), iter.Value())
+	}
+
+	return nil
+}
+
+// Get returns reflect.Value, so value is not set again after parsing.
+func (r *repeatedFlagVar) Get() interface{} {
+	return r.val
+}
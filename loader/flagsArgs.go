@@ -0,0 +1,212 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// ArgTag is the tag to bind positional arguments, like 'arg:"0"', 'arg:"1,required"' or 'arg:"rest"'.
+const ArgTag = "arg"
+
+// ArgRest is the tag value of the field to get the arguments after the numbered ones.
+const ArgRest = "rest"
+
+// ErrMissingArgument is returned when a required positional argument is not given.
+var ErrMissingArgument = errors.New("missing argument")
+
+// positionalArg is a field bound to positional arguments.
+type positionalArg struct {
+	name     string
+	index    int
+	rest     bool
+	required bool
+	field    reflect.Value
+}
+
+// parseArgTag returns the positional argument of the tag, false if field has no arg tag.
+func parseArgTag(name string, field reflect.StructField) (positionalArg, bool, error) {
+	values := internal.TagValueByKeys(field.Tag, ArgTag)
+	if values == nil {
+		return positionalArg{}, false, nil
+	}
+
+	arg := positionalArg{name: name}
+
+	for _, opt := range values[1:] {
+		if strings.TrimSpace(opt) == "required" {
+			arg.required = true
+		}
+	}
+
+	position := strings.TrimSpace(values[0])
+	if position == ArgRest {
+		arg.rest = true
+
+		return arg, true, nil
+	}
+
+	index, err := strconv.Atoi(position)
+	if err != nil || index < 0 {
+		return arg, true, fmt.Errorf("invalid %s tag %q of %q", ArgTag, position, name)
+	}
+
+	arg.index = index
+
+	return arg, true, nil
+}
+
+// setPositional sets the fields with arg tag from the positional arguments.
+//
+// Rest field gets the arguments after the highest numbered argument,
+// slices get one argument per element, other types get the arguments joined with space.
+func (l Flags) setPositional(to interface{}, args []string) error {
+	positional, err := positionalArgs(to)
+	if err != nil {
+		return err
+	}
+
+	restIndex := 0
+
+	for _, arg := range positional {
+		if !arg.rest && arg.index >= restIndex {
+			restIndex = arg.index + 1
+		}
+	}
+
+	for _, arg := range positional {
+		if arg.rest {
+			if err := setRestArgs(arg, args, restIndex); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if arg.index >= len(args) {
+			if arg.required {
+				return fmt.Errorf("%w: %s at position %d", ErrMissingArgument, arg.name, arg.index)
+			}
+
+			continue
+		}
+
+		if err := internal.SetReflectValueString(arg.name, args[arg.index], arg.field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// positionalArgs returns the fields with arg tag, subcommand fields are skipped.
+func positionalArgs(to interface{}) ([]positionalArg, error) {
+	tags := make(map[string]positionalArg)
+
+	var positional []positionalArg
+
+	var tagErr error
+
+	it := internal.StructIterator{
+		Value: to,
+		FieldNameFunc: func(outer string, field reflect.StructField) string {
+			if isSubcommand(field) {
+				return internal.SkipFieldTagValue
+			}
+
+			name := internal.JoinFieldNames(outer, strings.ToLower(field.Name), ".")
+
+			arg, ok, err := parseArgTag(name, field)
+			if err != nil && tagErr == nil {
+				tagErr = err
+			}
+
+			if ok {
+				tags[name] = arg
+			} else if !internal.IsStruct(field.Type) && !(field.Type.Kind() == reflect.Ptr && internal.IsStruct(field.Type.Elem())) {
+				return internal.SkipFieldTagValue
+			}
+
+			return name
+		},
+		IteratorFunc: func(fieldName string, field reflect.Value) error {
+			if arg, ok := tags[fieldName]; ok {
+				arg.field = field
+				positional = append(positional, arg)
+			}
+
+			return nil
+		},
+	}
+
+	if err := it.Iterate(); err != nil {
+		return nil, err
+	}
+
+	return positional, tagErr
+}
+
+// positionalUsage returns the positional arguments for the usage line, like "<file> [wait] [extra...]".
+//
+// Required arguments are in angle brackets, rest argument is the last one.
+func positionalUsage(positional []positionalArg) string {
+	args := make([]positionalArg, len(positional))
+	copy(args, positional)
+
+	sort.SliceStable(args, func(i, j int) bool {
+		if args[i].rest != args[j].rest {
+			return args[j].rest
+		}
+
+		return args[i].index < args[j].index
+	})
+
+	names := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		name := arg.name
+		if arg.rest {
+			name += "..."
+		}
+
+		if arg.required {
+			names = append(names, "<"+name+">")
+		} else {
+			names = append(names, "["+name+"]")
+		}
+	}
+
+	return strings.Join(names, " ")
+}
+
+func setRestArgs(arg positionalArg, args []string, restIndex int) error {
+	if restIndex >= len(args) {
+		if arg.required {
+			return fmt.Errorf("%w: %s", ErrMissingArgument, arg.name)
+		}
+
+		return nil
+	}
+
+	rest := args[restIndex:]
+
+	if arg.field.Kind() != reflect.Slice {
+		return internal.SetReflectValueString(arg.name, strings.Join(rest, " "), arg.field)
+	}
+
+	values := reflect.MakeSlice(arg.field.Type(), len(rest), len(rest))
+	for i, v := range rest {
+		if err := internal.SetReflectValueString(arg.name, v, values.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	arg.field.Set(values)
+
+	return nil
+}
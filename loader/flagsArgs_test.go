@@ -0,0 +1,81 @@
+package loader_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+func TestFlags_Positional(t *testing.T) {
+	type config struct {
+		Verbose bool          `cmd:"verbose,v"`
+		File    string        `arg:"0,required"`
+		Wait    time.Duration `arg:"1"`
+		Extra   []int         `arg:"rest"`
+	}
+
+	var c config
+
+	require.NoError(t, (loader.Flags{}).LoadSlice(&c, []string{"-v", "data.csv", "5s", "1", "2"}))
+	assert.Equal(t, config{Verbose: true, File: "data.csv", Wait: 5 * time.Second, Extra: []int{1, 2}}, c)
+
+	c = config{}
+
+	require.NoError(t, (loader.Flags{GNU: true}).LoadSlice(&c, []string{"data.csv", "-v"}))
+	assert.Equal(t, config{Verbose: true, File: "data.csv"}, c)
+
+	err := (loader.Flags{}).LoadSlice(&c, []string{"-v"})
+	assert.ErrorIs(t, err, loader.ErrMissingArgument)
+	assert.EqualError(t, err, "missing argument: file at position 0")
+
+	err = (loader.Flags{}).LoadSlice(&c, nil)
+	assert.ErrorIs(t, err, loader.ErrMissingArgument)
+
+	err = (loader.Flags{}).LoadSlice(&c, []string{"data.csv", "x"})
+	assert.Error(t, err)
+}
+
+func TestFlags_PositionalLenient(t *testing.T) {
+	type config struct {
+		Verbose bool   `cmd:"verbose,v"`
+		File    string `arg:"0,required"`
+		Extra   string `arg:"1"`
+	}
+
+	var c config
+
+	var rest []string
+
+	args := []string{"--unknown", "-v", "data.csv", "-x", "--", "-y"}
+
+	require.NoError(t, (loader.Flags{Lenient: true, Rest: &rest}).LoadSlice(&c, args))
+	assert.Equal(t, config{Verbose: true, File: "data.csv", Extra: "-y"}, c)
+	assert.Equal(t, []string{"--unknown", "data.csv", "-x", "-y"}, rest)
+
+	err := (loader.Flags{Lenient: true}).LoadSlice(&c, []string{"--unknown"})
+	assert.ErrorIs(t, err, loader.ErrMissingArgument)
+}
+
+func TestFlags_PositionalRest(t *testing.T) {
+	var c struct {
+		Command string `arg:"0"`
+		Query   string `arg:"rest,required"`
+	}
+
+	require.NoError(t, (loader.Flags{}).LoadSlice(&c, []string{"search", "go", "config"}))
+	assert.Equal(t, "search", c.Command)
+	assert.Equal(t, "go config", c.Query)
+
+	err := (loader.Flags{}).LoadSlice(&c, []string{"search"})
+	assert.EqualError(t, err, "missing argument: query")
+
+	var invalid struct {
+		File string `arg:"first"`
+	}
+
+	assert.EqualError(t, (loader.Flags{}).LoadSlice(&invalid, nil), `invalid arg tag "first" of "file"`)
+}
@@ -0,0 +1,271 @@
+package loader
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// FlagValue is a flag value of the bound fields.
+//
+// It implements flag.Value and pflag.Value, IsBoolFlag reports flags that do not need a value.
+type FlagValue interface {
+	String() string
+	Set(string) error
+	Type() string
+	IsBoolFlag() bool
+}
+
+// FlagSet is a flag set to bind config fields, like *flag.FlagSet with StdFlagSet or *pflag.FlagSet with PFlagSet.
+type FlagSet interface {
+	// Var defines a flag, shorthand is a one letter alias and it can be empty.
+	Var(value FlagValue, name, shorthand, usage string)
+	// Changed returns true if the flag is set in the arguments.
+	Changed(name string) bool
+}
+
+var _ Loader = (*FlagBinding)(nil)
+
+// FlagBinding is a loader of the flags bound to a FlagSet.
+//
+// Only the flags changed in arguments are set to the config, so it can be used in the loader list
+// after parsing the flags of the command.
+type FlagBinding struct {
+	flags  Flags
+	set    FlagSet
+	values map[string]*boundFlagValue
+}
+
+// Bind registers fields of 'to' to the flag set with the naming rules of Flags and returns a loader.
+//
+// Values are checked when flags are parsed but they are set to the config when the loader is called.
+// One letter names are registered as shorthand of the first name,
+// fields with only a one letter name use it as name and shorthand.
+// Same name in multiple fields or "h" name returns ErrFlagConflict.
+func (l Flags) Bind(set FlagSet, to interface{}) (*FlagBinding, error) {
+	l.names = make(map[string][]internal.FieldName)
+	l.usage = newFlagUsage()
+
+	defined := make(map[string]string)
+	values := make(map[string]*boundFlagValue)
+
+	bind := func(fieldName string, field reflect.Value) error {
+		var long []internal.FieldName
+
+		var short string
+
+		for _, name := range l.fieldNames(fieldName) {
+			if name.Name == "h" {
+				return fmt.Errorf("%w: -h of %q is the help flag", ErrFlagConflict, fieldName)
+			}
+
+			if owner, ok := defined[name.Name]; ok {
+				return fmt.Errorf("%w: -%s of %q is already defined by %q", ErrFlagConflict, name.Name, fieldName, owner)
+			}
+
+			defined[name.Name] = fieldName
+
+			if len(name.Name) == 1 && short == "" && !name.Deprecated {
+				short = name.Name
+
+				continue
+			}
+
+			long = append(long, name)
+		}
+
+		if len(long) == 0 {
+			if short == "" {
+				return nil
+			}
+
+			long = []internal.FieldName{{Name: short}}
+		}
+
+		// embedded structs without names
+		if isDecodeKind(field.Kind()) && strings.HasSuffix(long[0].Name, "-") {
+			return nil
+		}
+
+		for i, name := range long {
+			usage := l.usage.fieldUsage(fieldName)
+			if name.Deprecated {
+				usage = fmt.Sprintf("deprecated, use --%s", fieldName)
+			}
+
+			var shorthand string
+			if i == 0 {
+				shorthand = short
+			}
+
+			values[name.Name] = newBoundFlagValue(name.Name, field)
+			set.Var(values[name.Name], name.Name, shorthand, usage)
+		}
+
+		return nil
+	}
+
+	it := internal.StructIterator{
+		Value:         to,
+		FieldNameFunc: l.FieldNameFunc,
+		IteratorFunc:  bind,
+		StructFunc:    bind,
+	}
+
+	if err := it.Iterate(); err != nil {
+		return nil, err
+	}
+
+	// usage is only used to define the flags
+	l.usage = nil
+
+	return &FlagBinding{flags: l, set: set, values: values}, nil
+}
+
+// LoadWithContext sets the changed flags to 'to'.
+func (b *FlagBinding) LoadWithContext(ctx context.Context, _ string, to interface{}) error {
+	l := b.flags
+
+	apply := func(fieldName string, field reflect.Value) error {
+		for _, name := range l.fieldNames(fieldName) {
+			value, ok := b.values[name.Name]
+			if !ok || !b.set.Changed(name.Name) {
+				continue
+			}
+
+			warnDeprecatedFlag(ctx, name, fieldName)
+
+			return value.apply(field)
+		}
+
+		return nil
+	}
+
+	it := internal.StructIterator{
+		Value:         to,
+		FieldNameFunc: l.FieldNameFunc,
+		IteratorFunc:  apply,
+		StructFunc:    apply,
+	}
+
+	return it.Iterate()
+}
+
+// Load is just same as LoadWithContext without context.
+func (b *FlagBinding) Load(appName string, to interface{}) error {
+	return b.LoadWithContext(context.Background(), appName, to)
+}
+
+// boundFlagValue keeps the raw values of the flag until the loader is called.
+type boundFlagValue struct {
+	name     string
+	typ      reflect.Type
+	defValue string
+	values   []string
+}
+
+func newBoundFlagValue(name string, field reflect.Value) *boundFlagValue {
+	v := &boundFlagValue{name: name, typ: field.Type()}
+
+	if !field.IsZero() && !isDecodeKind(field.Kind()) {
+		v.defValue = fmt.Sprint(reflect.Indirect(field).Interface())
+	}
+
+	return v
+}
+
+func (v *boundFlagValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	if len(v.values) > 0 {
+		return v.values[len(v.values)-1]
+	}
+
+	return v.defValue
+}
+
+// Set checks the value with a new value of the field type and keeps it.
+func (v *boundFlagValue) Set(s string) error {
+	if err := setValueString(v.name, s, reflect.New(v.typ).Elem()); err != nil {
+		return err
+	}
+
+	v.values = append(v.values, s)
+
+	return nil
+}
+
+func (v *boundFlagValue) Type() string {
+	return flagTypeName(v.typ)
+}
+
+func (v *boundFlagValue) IsBoolFlag() bool {
+	typ := v.typ
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return typ.Kind() == reflect.Bool
+}
+
+// apply sets the values to the field, slices and maps are appended like repeated flags.
+func (v *boundFlagValue) apply(field reflect.Value) error {
+	if field.Kind() == reflect.Slice || field.Kind() == reflect.Map {
+		repeated := &repeatedFlagVar{name: v.name, val: field}
+
+		for _, s := range v.values {
+			if err := repeated.Set(s); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if len(v.values) == 0 {
+		return nil
+	}
+
+	return setValueString(v.name, v.values[len(v.values)-1], field)
+}
+
+// StdFlagSet is a FlagSet adapter of *flag.FlagSet.
+type StdFlagSet struct {
+	*flag.FlagSet
+
+	shorthands map[string]string
+}
+
+// NewStdFlagSet returns the adapter of the flag set.
+func NewStdFlagSet(set *flag.FlagSet) *StdFlagSet {
+	return &StdFlagSet{FlagSet: set, shorthands: make(map[string]string)}
+}
+
+// Var defines the flag and shorthand with same value.
+func (s *StdFlagSet) Var(value FlagValue, name, shorthand, usage string) {
+	s.FlagSet.Var(value, name, usage)
+
+	if shorthand != "" && shorthand != name {
+		s.FlagSet.Var(value, shorthand, usage)
+		s.shorthands[name] = shorthand
+	}
+}
+
+// Changed returns true if the flag or its shorthand is set in the arguments.
+func (s *StdFlagSet) Changed(name string) bool {
+	changed := false
+
+	s.FlagSet.Visit(func(fl *flag.Flag) {
+		if fl.Name == name || (s.shorthands[name] != "" && fl.Name == s.shorthands[name]) {
+			changed = true
+		}
+	})
+
+	return changed
+}
@@ -0,0 +1,151 @@
+package loader_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+type bindConfig struct {
+	Name  string   `cmd:"name,n" usage:"name of the service"`
+	Port  int      `cmd:"port,listen!deprecated"`
+	Debug bool     `cmd:"debug,d"`
+	Tags  []string `cmd:"tag"`
+	DB    struct {
+		Host string
+	} `cmd:"db"`
+}
+
+func TestFlags_Bind(t *testing.T) {
+	set := flag.NewFlagSet("app", flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+
+	c := bindConfig{Name: "default", Port: 80}
+
+	binding, err := (loader.Flags{}).Bind(loader.NewStdFlagSet(set), &c)
+	require.NoError(t, err)
+
+	require.NoError(t, set.Parse([]string{"-d", "--listen", "8080", "--tag", "a", "--tag", "b", "--db-host", "x"}))
+
+	// parsing does not change the config
+	assert.Equal(t, bindConfig{Name: "default", Port: 80}, c)
+
+	// values set by other loaders are kept when flags are not changed
+	c.Name = "from-env"
+
+	require.NoError(t, binding.Load("app", &c))
+
+	assert.Equal(t, "from-env", c.Name)
+	assert.Equal(t, 8080, c.Port)
+	assert.True(t, c.Debug)
+	assert.Equal(t, []string{"a", "b"}, c.Tags)
+	assert.Equal(t, "x", c.DB.Host)
+
+	assert.Equal(t, "name of the service", set.Lookup("name").Usage)
+	assert.Equal(t, "default", set.Lookup("name").DefValue)
+
+	err = set.Parse([]string{"--port", "abc"})
+	assert.ErrorContains(t, err, `invalid value "abc" for flag -port`)
+}
+
+func TestFlags_BindFlagSet(t *testing.T) {
+	set := &fakeFlagSet{values: map[string]loader.FlagValue{}, shorthands: map[string]string{}}
+
+	var c bindConfig
+
+	binding, err := (loader.Flags{}).Bind(set, &c)
+	require.NoError(t, err)
+
+	assert.Equal(t, "n", set.shorthands["name"])
+	assert.Equal(t, "string", set.values["name"].Type())
+	assert.True(t, set.values["debug"].IsBoolFlag())
+
+	require.NoError(t, set.set("name", "piet"))
+
+	require.NoError(t, binding.Load("app", &c))
+	assert.Equal(t, "piet", c.Name)
+	assert.False(t, c.Debug)
+
+	var short struct {
+		Port    int  `cmd:"port,p"`
+		Profile bool `cmd:"profile,p"`
+	}
+
+	set = &fakeFlagSet{values: map[string]loader.FlagValue{}, shorthands: map[string]string{}}
+
+	_, err = (loader.Flags{}).Bind(set, &short)
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+	assert.ErrorContains(t, err, `-p of "profile" is already defined by "port"`)
+
+	var shortOnly struct {
+		Port    int  `cmd:"p"`
+		Profile bool `cmd:"profile,p"`
+	}
+
+	set = &fakeFlagSet{values: map[string]loader.FlagValue{}, shorthands: map[string]string{}}
+
+	_, err = (loader.Flags{}).Bind(set, &shortOnly)
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+
+	var help struct {
+		Host string `cmd:"host,h"`
+	}
+
+	_, err = (loader.Flags{}).Bind(set, &help)
+	assert.ErrorContains(t, err, `-h of "host" is the help flag`)
+
+	var conflict struct {
+		Port int `cmd:"port"`
+		Addr int `cmd:"addr,port"`
+	}
+
+	_, err = (loader.Flags{}).Bind(set, &conflict)
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+}
+
+// fakeFlagSet works like pflag, shorthands are kept with the flag.
+type fakeFlagSet struct {
+	values     map[string]loader.FlagValue
+	shorthands map[string]string
+	changed    map[string]bool
+}
+
+func (s *fakeFlagSet) Var(value loader.FlagValue, name, shorthand, _ string) {
+	s.values[name] = value
+	s.shorthands[name] = shorthand
+}
+
+func (s *fakeFlagSet) Changed(name string) bool {
+	return s.changed[name]
+}
+
+func (s *fakeFlagSet) set(name, value string) error {
+	if s.changed == nil {
+		s.changed = map[string]bool{}
+	}
+
+	s.changed[name] = true
+
+	return s.values[name].Set(value)
+}
+
+func TestFlags_BindShortOnly(t *testing.T) {
+	set := flag.NewFlagSet("app", flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+
+	var c struct {
+		Verbose bool `cmd:"v"`
+	}
+
+	binding, err := (loader.Flags{}).Bind(loader.NewStdFlagSet(set), &c)
+	require.NoError(t, err)
+
+	require.NoError(t, set.Parse([]string{"-v"}))
+	require.NoError(t, binding.Load("app", &c))
+	assert.True(t, c.Verbose)
+}
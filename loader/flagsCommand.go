@@ -0,0 +1,122 @@
+package loader
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// SubcommandOption is the option in the cmd tag to mark a nested struct as subcommand, like 'cmd:"serve,subcommand"'.
+const SubcommandOption = "subcommand"
+
+// flagCommand is a subcommand field of the config struct.
+type flagCommand struct {
+	names []string
+	usage string
+	index int
+}
+
+// isSubcommand returns true if cmd tag of the field has SubcommandOption.
+func isSubcommand(field reflect.StructField) bool {
+	for _, v := range internal.TagValueByKeys(field.Tag, CmdTag) {
+		if strings.TrimSpace(v) == SubcommandOption {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subcommands returns the subcommand fields of the struct, only direct fields can be subcommands.
+func subcommands(to interface{}) []flagCommand {
+	v := reflect.ValueOf(to)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	typ := v.Elem().Type()
+
+	var commands []flagCommand
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" || !isSubcommand(field) {
+			continue
+		}
+
+		command := flagCommand{index: i}
+
+		for _, name := range internal.TagValueByKeys(field.Tag, CmdTag) {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" && name != SubcommandOption {
+				command.names = append(command.names, name)
+			}
+		}
+
+		if len(command.names) == 0 {
+			command.names = []string{strings.ToLower(field.Name)}
+		}
+
+		for _, tag := range FlagUsageTags {
+			if usage, ok := field.Tag.Lookup(tag); ok {
+				command.usage = usage
+
+				break
+			}
+		}
+
+		commands = append(commands, command)
+	}
+
+	return commands
+}
+
+// findCommand returns the subcommand with the name.
+func findCommand(commands []flagCommand, name string) (flagCommand, bool) {
+	for _, command := range commands {
+		for _, n := range command.names {
+			if n == name {
+				return command, true
+			}
+		}
+	}
+
+	return flagCommand{}, false
+}
+
+// loadCommand loads the subcommand struct with the arguments after the subcommand name.
+//
+// Flags of the subcommand are not prefixed with the subcommand name, nil pointer struct is created if needed.
+// Other loaders can also fill the subcommand structs, so use Flags.Command to know the chosen subcommand.
+func (l Flags) loadCommand(ctx context.Context, to interface{}, command flagCommand, args []string) error {
+	field := reflect.ValueOf(to).Elem().Field(command.index)
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+	} else {
+		field = field.Addr()
+	}
+
+	var path string
+
+	sub := l
+	sub.Command = &path
+	sub.appName = strings.TrimSpace(l.appName + " " + command.names[0])
+	if sub.root == nil {
+		sub.root, sub.rootAppName = to, l.appName
+	}
+	// Env loader reads the fields of the subcommand with its name
+	sub.envPrefix = Env{}.FieldNameFunc(l.envPrefix, reflect.TypeOf(to).Elem().Field(command.index))
+
+	err := sub.loadSlice(ctx, field.Interface(), args)
+
+	if l.Command != nil {
+		*l.Command = strings.TrimSpace(command.names[0] + " " + path)
+	}
+
+	return err
+}
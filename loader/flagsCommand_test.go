@@ -0,0 +1,155 @@
+package loader_test
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+type commandConfig struct {
+	Verbose bool `cmd:"verbose,v"`
+	Serve   struct {
+		Port int    `cmd:"port,p"`
+		Dir  string `arg:"0"`
+	} `cmd:"serve,s,subcommand" usage:"start the server"`
+	DB *struct {
+		Migrate struct {
+			Steps int `cmd:"steps"`
+		} `cmd:"migrate,subcommand"`
+	} `cmd:"db,subcommand"`
+}
+
+func TestFlags_Subcommand(t *testing.T) {
+	var c commandConfig
+
+	var command string
+
+	var rest []string
+
+	args := []string{"-v", "serve", "--port", "80", "public"}
+
+	require.NoError(t, (loader.Flags{Command: &command, Rest: &rest}).LoadSlice(&c, args))
+
+	assert.Equal(t, "serve", command)
+	assert.True(t, c.Verbose)
+	assert.Equal(t, 80, c.Serve.Port)
+	assert.Equal(t, "public", c.Serve.Dir)
+	assert.Equal(t, []string{"public"}, rest)
+	assert.Nil(t, c.DB)
+
+	// unknown flags before the subcommand do not hide it
+	c = commandConfig{}
+	command = ""
+
+	args = []string{"--unknown", "serve", "--port", "80", "public"}
+
+	require.NoError(t, (loader.Flags{Lenient: true, Command: &command}).LoadSlice(&c, args))
+	assert.Equal(t, "serve", command)
+	assert.Equal(t, 80, c.Serve.Port)
+	assert.Equal(t, "public", c.Serve.Dir)
+
+	// flags of subcommands are scoped
+	err := (loader.Flags{NoUsage: true}).LoadSlice(&c, []string{"--port", "80"})
+	assert.EqualError(t, err, "flags parsing error: flag provided but not defined: -port")
+
+	c = commandConfig{}
+
+	require.NoError(t, (loader.Flags{Command: &command, GNU: true}).LoadSlice(&c, []string{"db", "migrate", "--steps", "2"}))
+
+	assert.Equal(t, "db migrate", command)
+	require.NotNil(t, c.DB)
+	assert.Equal(t, 2, c.DB.Migrate.Steps)
+
+	// flags of the parent are not flags of the subcommand in GNU mode
+	err = (loader.Flags{GNU: true, NoUsage: true}).LoadSlice(&c, []string{"db", "migrate", "-v"})
+	assert.EqualError(t, err, "flags parsing error: flag provided but not defined: -v")
+
+	require.NoError(t, (loader.Flags{Command: &command, GNU: true}).LoadSlice(&c, []string{"-v", "s", "-p8080"}))
+	assert.Equal(t, "serve", command)
+	assert.Equal(t, 8080, c.Serve.Port)
+
+	require.NoError(t, (loader.Flags{Command: &command}).LoadSlice(&c, []string{"-v"}))
+	assert.Empty(t, command)
+}
+
+func TestFlags_SubcommandPositional(t *testing.T) {
+	var c struct {
+		File  string `arg:"0,required"`
+		Serve struct {
+			Dir string `arg:"0,required"`
+		} `cmd:"serve,subcommand"`
+	}
+
+	var command string
+
+	require.NoError(t, (loader.Flags{Command: &command}).LoadSlice(&c, []string{"serve", "public"}))
+	assert.Equal(t, "serve", command)
+	assert.Equal(t, "public", c.Serve.Dir)
+	assert.Empty(t, c.File)
+
+	err := (loader.Flags{}).LoadSlice(&c, []string{"serve"})
+	assert.EqualError(t, err, "missing argument: dir at position 0")
+
+	require.NoError(t, (loader.Flags{Command: &command}).LoadSlice(&c, []string{"data.csv"}))
+	assert.Empty(t, command)
+	assert.Equal(t, "data.csv", c.File)
+}
+
+func TestFlags_SubcommandHelp(t *testing.T) {
+	var c commandConfig
+
+	var out bytes.Buffer
+
+	err := (loader.Flags{Args: []string{"-h"}, Output: &out}).Load("app", &c)
+	require.ErrorIs(t, err, flag.ErrHelp)
+
+	assert.Equal(t, `Usage of app:
+  -verbose, -v
+    	[$VERBOSE]
+
+Commands:
+  serve, s
+    	start the server
+  db
+`, out.String())
+
+	out.Reset()
+
+	err = (loader.Flags{Args: []string{"serve", "-h"}, Output: &out}).Load("app", &c)
+	require.ErrorIs(t, err, flag.ErrHelp)
+
+	assert.Equal(t, "Usage of app serve [dir]:\n  -port, -p int\n    \t[$SERVE_PORT]\n", out.String())
+
+	out.Reset()
+
+	err = (loader.Flags{Args: []string{"db", "migrate", "-h"}, Output: &out}).Load("app", &c)
+	require.ErrorIs(t, err, flag.ErrHelp)
+
+	assert.Equal(t, "Usage of app db migrate:\n  -steps int\n    \t[$DB_MIGRATE_STEPS]\n", out.String())
+
+	// env names in help are read by Env loader
+	c = commandConfig{}
+
+	require.NoError(t, (loader.Env{Source: loader.EnvMap{"SERVE_PORT": "80", "DB_MIGRATE_STEPS": "2"}}).Load("app", &c))
+	assert.Equal(t, 80, c.Serve.Port)
+	require.NotNil(t, c.DB)
+	assert.Equal(t, 2, c.DB.Migrate.Steps)
+
+	var args struct {
+		File  string   `arg:"0,required"`
+		Wait  string   `arg:"1"`
+		Extra []string `arg:"rest"`
+	}
+
+	out.Reset()
+
+	err = (loader.Flags{Args: []string{"-h"}, Output: &out}).Load("app", &args)
+	require.ErrorIs(t, err, flag.ErrHelp)
+
+	assert.Equal(t, "Usage of app <file> [wait] [extra...]:\n", out.String())
+}
@@ -0,0 +1,509 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// CompletionFlag is the hidden flag to write the completion script, like '--completion bash'.
+//
+// Set it to empty to disable, it is not added if a field already uses the name.
+var CompletionFlag = "completion"
+
+// CompletionValidateTag is the validation tag to read completion rules:
+// 'oneof=a b' completes values and 'file', 'filepath', 'dir', 'dirpath' complete paths.
+var CompletionValidateTag = "validate"
+
+// ErrUnsupportedShell is returned when completion script is requested for an unknown shell.
+var ErrUnsupportedShell = errors.New("unsupported shell")
+
+// CompletionShells are the supported shells of completion scripts.
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// CompletionError is returned from Flags after the completion script is written with the CompletionFlag.
+//
+// It wraps flag.ErrHelp like HelpError, so the application can exit without failure.
+type CompletionError struct {
+	Shell string
+}
+
+func (e *CompletionError) Error() string {
+	return "flags: completion script requested for " + e.Shell
+}
+
+func (e *CompletionError) Unwrap() error {
+	return flag.ErrHelp
+}
+
+// Completion writes the completion script of the flags of 'to' for the shell, see CompletionShells.
+//
+// Command name is the last part of appName. Subcommands and their flags are completed after the command name.
+func (l Flags) Completion(w io.Writer, shell, appName string, to interface{}) error {
+	spec, err := l.completionSpec(context.Background(), to)
+	if err != nil {
+		return err
+	}
+
+	return renderCompletion(w, shell, appName, spec)
+}
+
+// writeCompletion writes the script of the main command to Output or stdout.
+func (l Flags) writeCompletion(ctx context.Context, shell string, to interface{}) error {
+	w := l.Output
+	if w == nil {
+		w = os.Stdout
+	}
+
+	appName := l.appName
+	if l.root != nil {
+		to, appName = l.root, l.rootAppName
+	}
+
+	spec, err := l.completionSpec(ctx, to)
+	if err != nil {
+		return err
+	}
+
+	if err := renderCompletion(w, shell, appName, spec); err != nil {
+		return err
+	}
+
+	return &CompletionError{Shell: shell}
+}
+
+// completionSpec is a command in completion scripts.
+type completionSpec struct {
+	names    []string
+	usage    string
+	flags    []completionFlag
+	commands []completionSpec
+}
+
+// completionSpec returns the flags and subcommands of 'to', subcommand structs are not changed.
+func (l Flags) completionSpec(ctx context.Context, to interface{}) (completionSpec, error) {
+	l.names = make(map[string][]internal.FieldName)
+	l.usage = newFlagUsage()
+
+	flags := flag.FlagSet{}
+	if _, err := l.addFlags(ctx, &flags, to); err != nil {
+		return completionSpec{}, err
+	}
+
+	spec := completionSpec{flags: l.usage.completionFlags()}
+
+	for _, command := range subcommands(to) {
+		typ := reflect.TypeOf(to).Elem().Field(command.index).Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		sub, err := l.completionSpec(ctx, reflect.New(typ).Interface())
+		if err != nil {
+			return completionSpec{}, err
+		}
+
+		sub.names, sub.usage = command.names, strings.ReplaceAll(command.usage, "\n", " ")
+		spec.commands = append(spec.commands, sub)
+	}
+
+	return spec, nil
+}
+
+// commandNames returns all names of the subcommands.
+func (s completionSpec) commandNames() []string {
+	var names []string
+	for _, command := range s.commands {
+		names = append(names, command.names...)
+	}
+
+	return names
+}
+
+// completionRules returns the values of oneof rule and path completion of the validation tag.
+func completionRules(tag string) ([]string, string) {
+	var values []string
+
+	var complete string
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch key {
+		case "oneof":
+			values = splitOneOf(param)
+		case "file", "filepath":
+			complete = "file"
+		case "dir", "dirpath":
+			complete = "dir"
+		}
+	}
+
+	return values, complete
+}
+
+var oneOfRgx = regexp.MustCompile(`'[^']*'|\S+`)
+
+func splitOneOf(param string) []string {
+	values := oneOfRgx.FindAllString(param, -1)
+	for i := range values {
+		values[i] = strings.Trim(values[i], "'")
+	}
+
+	return values
+}
+
+// completionFlag is a flag shown in completion scripts.
+type completionFlag struct {
+	names    []string
+	usage    string
+	isBool   bool
+	values   []string
+	complete string
+}
+
+// completionFlags returns flags without deprecated names, one letter names start with '-' others with '--'.
+func (u *flagUsage) completionFlags() []completionFlag {
+	flags := make([]completionFlag, 0, len(u.fields))
+
+	for _, f := range u.fields {
+		if f.typeName == "" {
+			continue
+		}
+
+		c := completionFlag{
+			usage:    strings.ReplaceAll(f.usage, "\n", " "),
+			isBool:   f.typeName == "bool",
+			values:   f.values,
+			complete: f.complete,
+		}
+
+		for _, name := range f.names {
+			if name.Deprecated {
+				continue
+			}
+
+			if len(name.Name) == 1 {
+				c.names = append(c.names, "-"+name.Name)
+			} else {
+				c.names = append(c.names, "--"+name.Name)
+			}
+		}
+
+		if len(c.names) > 0 {
+			flags = append(flags, c)
+		}
+	}
+
+	return flags
+}
+
+func renderCompletion(w io.Writer, shell, appName string, spec completionSpec) error {
+	cmd := completionCommand(appName)
+
+	switch shell {
+	case "bash":
+		renderBashCompletion(w, cmd, spec)
+	case "zsh":
+		renderZshCompletion(w, cmd, spec)
+	case "fish":
+		renderFishCompletion(w, cmd, spec)
+	default:
+		return fmt.Errorf("%w %q, use one of %s", ErrUnsupportedShell, shell, strings.Join(CompletionShells, ", "))
+	}
+
+	return nil
+}
+
+// completionCommand returns the command name, last part of appName or the executable name.
+func completionCommand(appName string) string {
+	if name := path.Base(strings.TrimRight(appName, "/")); appName != "" && name != "/" {
+		return name
+	}
+
+	return path.Base(os.Args[0])
+}
+
+var funcNameRgx = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// completionLevel is a command with its path of first names separated by space, empty for the main command.
+type completionLevel struct {
+	path string
+	spec completionSpec
+}
+
+// completionLevels returns the command and all nested subcommands in order.
+func completionLevels(path string, spec completionSpec) []completionLevel {
+	levels := []completionLevel{{path: path, spec: spec}}
+
+	for _, command := range spec.commands {
+		levels = append(levels, completionLevels(strings.TrimSpace(path+" "+command.names[0]), command)...)
+	}
+
+	return levels
+}
+
+func renderBashCompletion(w io.Writer, cmd string, spec completionSpec) {
+	fn := "_" + funcNameRgx.ReplaceAllString(cmd, "_") + "_completion"
+
+	fmt.Fprintf(w, "# bash completion for %s, load with: source <(%s --%s bash)\n", cmd, cmd, CompletionFlag)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+
+	if len(spec.commands) == 0 {
+		fmt.Fprintf(w, "\n")
+		renderBashFlags(w, "\t", spec)
+	} else {
+		levels := completionLevels("", spec)
+
+		// subcommand is found from the words before the current one
+		fmt.Fprintf(w, "\tlocal cmd='' i\n\n")
+		fmt.Fprintf(w, "\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+		fmt.Fprintf(w, "\t\tcase \"$cmd/${COMP_WORDS[i]}\" in\n")
+
+		for _, level := range levels {
+			for _, command := range level.spec.commands {
+				patterns := make([]string, 0, len(command.names))
+				for _, name := range command.names {
+					patterns = append(patterns, shellQuote(level.path+"/"+name))
+				}
+
+				fmt.Fprintf(w, "\t\t%s) cmd=%s ;;\n", strings.Join(patterns, "|"), shellQuote(strings.TrimSpace(level.path+" "+command.names[0])))
+			}
+		}
+
+		fmt.Fprintf(w, "\t\tesac\n\tdone\n\n")
+		fmt.Fprintf(w, "\tcase \"$cmd\" in\n")
+
+		for _, level := range levels {
+			fmt.Fprintf(w, "\t%s)\n", shellQuote(level.path))
+			renderBashFlags(w, "\t\t", level.spec)
+			fmt.Fprintf(w, "\t\t;;\n")
+		}
+
+		fmt.Fprintf(w, "\tesac\n")
+	}
+
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "complete -o default -F %s %s\n", fn, cmd)
+}
+
+// renderBashFlags writes the completion of flag values and the words of the command.
+func renderBashFlags(w io.Writer, indent string, spec completionSpec) {
+	fmt.Fprintf(w, "%scase \"$prev\" in\n", indent)
+
+	all := []string{"--help"}
+
+	for _, f := range spec.flags {
+		all = append(all, f.names...)
+
+		if f.isBool {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s%s)\n", indent, strings.Join(f.names, "|"))
+
+		switch {
+		case len(f.values) > 0:
+			// values are split by new line, so they can have spaces, and quoted in the reply
+			fmt.Fprintf(w, "%s\tlocal IFS=$'\\n'\n", indent)
+			fmt.Fprintf(w, "%s\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", indent, "$'"+escapeValues(bashANSIEscaper, f.values, `\n`)+"'")
+			fmt.Fprintf(w, "%s\t[ ${#COMPREPLY[@]} -gt 0 ] && COMPREPLY=($(printf '%%q\\n' \"${COMPREPLY[@]}\"))\n", indent)
+		case f.complete == "file":
+			fmt.Fprintf(w, "%s\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n", indent)
+		case f.complete == "dir":
+			fmt.Fprintf(w, "%s\tCOMPREPLY=($(compgen -d -- \"$cur\"))\n", indent)
+		}
+
+		fmt.Fprintf(w, "%s\treturn\n%s\t;;\n", indent, indent)
+	}
+
+	all = append(all, spec.commandNames()...)
+
+	fmt.Fprintf(w, "%sesac\n\n", indent)
+	fmt.Fprintf(w, "%sCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", indent, shellQuote(strings.Join(all, " ")))
+}
+
+var zshEscaper = strings.NewReplacer(`[`, `\[`, `]`, `\]`, `:`, `\:`)
+
+// zshValueEscaper escapes a value in the '(a b)' action of _arguments.
+var zshValueEscaper = strings.NewReplacer(
+	`\`, `\\`, ` `, `\ `, `(`, `\(`, `)`, `\)`, `'`, `\'`, `"`, `\"`, `$`, `\$`, "`", "\\`", `:`, `\:`,
+)
+
+func renderZshCompletion(w io.Writer, cmd string, spec completionSpec) {
+	fn := "_" + funcNameRgx.ReplaceAllString(cmd, "_")
+
+	fmt.Fprintf(w, "#compdef %s\n\n", cmd)
+	renderZshFunction(w, fn, spec)
+	fmt.Fprintf(w, "if [ \"$funcstack[1]\" = \"%s\" ]; then\n\t%s \"$@\"\nelse\n\tcompdef %s %s\nfi\n", fn, fn, fn, cmd)
+}
+
+// renderZshFunction writes the function of the command and the functions of its subcommands.
+func renderZshFunction(w io.Writer, fn string, spec completionSpec) {
+	specs := []string{shellQuote("--help[show help]")}
+
+	for _, f := range spec.flags {
+		for _, name := range f.names {
+			arg := name + "[" + zshEscaper.Replace(f.usage) + "]"
+
+			if !f.isBool {
+				message := strings.TrimLeft(f.names[0], "-")
+
+				switch {
+				case len(f.values) > 0:
+					arg += ":" + message + ":(" + escapeValues(zshValueEscaper, f.values, " ") + ")"
+				case f.complete == "file":
+					arg += ":" + message + ":_files"
+				case f.complete == "dir":
+					arg += ":" + message + ":_files -/"
+				default:
+					arg += ":" + message + ":"
+				}
+			}
+
+			specs = append(specs, shellQuote(arg))
+		}
+	}
+
+	if len(spec.commands) == 0 {
+		fmt.Fprintf(w, "%s() {\n", fn)
+		fmt.Fprintf(w, "\t_arguments \\\n\t\t%s\n", strings.Join(specs, " \\\n\t\t"))
+		fmt.Fprintf(w, "}\n\n")
+
+		return
+	}
+
+	// first argument is the subcommand, remaining words are completed with the function of the subcommand
+	var commands []string
+
+	for _, command := range spec.commands {
+		for _, name := range command.names {
+			commands = append(commands, zshValueEscaper.Replace(name)+`\:`+zshValueEscaper.Replace(command.usage))
+		}
+	}
+
+	specs = append(specs, shellQuote("1: :(("+strings.Join(commands, " ")+"))"), shellQuote("*::arg:->args"))
+
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "\tlocal context state state_descr line\n\ttypeset -A opt_args\n\n")
+	fmt.Fprintf(w, "\t_arguments -C \\\n\t\t%s\n\n", strings.Join(specs, " \\\n\t\t"))
+	fmt.Fprintf(w, "\tcase $state in\n\targs)\n\t\tcase $line[1] in\n")
+
+	for _, command := range spec.commands {
+		fmt.Fprintf(w, "\t\t%s) %s ;;\n", strings.Join(command.names, "|"), zshCommandFunc(fn, command))
+	}
+
+	fmt.Fprintf(w, "\t\tesac\n\t\t;;\n\tesac\n")
+	fmt.Fprintf(w, "}\n\n")
+
+	for _, command := range spec.commands {
+		renderZshFunction(w, zshCommandFunc(fn, command), command)
+	}
+}
+
+func zshCommandFunc(fn string, command completionSpec) string {
+	return fn + "_" + funcNameRgx.ReplaceAllString(command.names[0], "_")
+}
+
+var fishEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// fishValueEscaper escapes a value in the '-a' argument list, fish splits the list like command arguments.
+var fishValueEscaper = strings.NewReplacer(
+	`\`, `\\`, ` `, `\ `, `(`, `\(`, `)`, `\)`, `'`, `\'`, `"`, `\"`, `$`, `\$`, `*`, `\*`, `?`, `\?`,
+	`{`, `\{`, `}`, `\}`, `;`, `\;`, `&`, `\&`, `|`, `\|`, `#`, `\#`, `~`, `\~`, `<`, `\<`, `>`, `\>`,
+)
+
+func renderFishCompletion(w io.Writer, cmd string, spec completionSpec) {
+	fmt.Fprintf(w, "complete -c %s -l help -d 'show help'\n", cmd)
+
+	renderFishCommand(w, cmd, spec, nil)
+}
+
+// renderFishCommand writes the flags and subcommands of the command,
+// seen are the conditions of the parent subcommands to be in the command line.
+func renderFishCommand(w io.Writer, cmd string, spec completionSpec, seen []string) {
+	conditions := seen
+	if names := spec.commandNames(); len(names) > 0 {
+		// flags and subcommands of the command are completed until a subcommand is given
+		conditions = append(conditions[:len(conditions):len(conditions)],
+			"not __fish_seen_subcommand_from "+escapeValues(fishValueEscaper, names, " "))
+	}
+
+	base := "complete -c " + cmd
+	if len(conditions) > 0 {
+		base += " -n '" + fishEscaper.Replace(strings.Join(conditions, "; and ")) + "'"
+	}
+
+	for _, f := range spec.flags {
+		line := base
+
+		for _, name := range f.names {
+			if strings.HasPrefix(name, "--") {
+				line += " -l " + strings.TrimPrefix(name, "--")
+			} else {
+				line += " -s " + strings.TrimPrefix(name, "-")
+			}
+		}
+
+		if f.usage != "" {
+			line += " -d '" + fishEscaper.Replace(f.usage) + "'"
+		}
+
+		if !f.isBool {
+			switch {
+			case len(f.values) > 0:
+				line += " -x -a '" + fishEscaper.Replace(escapeValues(fishValueEscaper, f.values, " ")) + "'"
+			case f.complete == "file":
+				line += " -r -F"
+			case f.complete == "dir":
+				line += " -x -a '(__fish_complete_directories)'"
+			default:
+				line += " -r"
+			}
+		}
+
+		fmt.Fprintln(w, line)
+	}
+
+	for _, command := range spec.commands {
+		line := base + " -f -a '" + fishEscaper.Replace(escapeValues(fishValueEscaper, command.names, " ")) + "'"
+		if command.usage != "" {
+			line += " -d '" + fishEscaper.Replace(command.usage) + "'"
+		}
+
+		fmt.Fprintln(w, line)
+	}
+
+	for _, command := range spec.commands {
+		condition := "__fish_seen_subcommand_from " + escapeValues(fishValueEscaper, command.names, " ")
+		renderFishCommand(w, cmd, command, append(seen[:len(seen):len(seen)], condition))
+	}
+}
+
+// shellQuote quotes the value with single quotes for bash and zsh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// bashANSIEscaper escapes a value in $'...' string.
+var bashANSIEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// escapeValues escapes the values and joins them with the separator.
+func escapeValues(escaper *strings.Replacer, values []string, sep string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = escaper.Replace(v)
+	}
+
+	return strings.Join(escaped, sep)
+}
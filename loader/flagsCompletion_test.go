@@ -0,0 +1,195 @@
+package loader_test
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+type completionConfig struct {
+	Level  string `cmd:"level,l" usage:"log level" validate:"required,oneof=debug info 'very verbose'"`
+	Config string `cmd:"config" usage:"config file" validate:"file"`
+	Dir    string `cmd:"dir" validate:"dirpath"`
+	Debug  bool   `cmd:"debug,old!deprecated" usage:"it's [debug]"`
+}
+
+func TestFlags_Completion(t *testing.T) {
+	var c completionConfig
+
+	var out bytes.Buffer
+
+	require.NoError(t, loader.Flags{}.Completion(&out, "bash", "tools/myapp", &c))
+
+	assert.Equal(t, `# bash completion for myapp, load with: source <(myapp --completion bash)
+_myapp_completion() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+	case "$prev" in
+	--level|-l)
+		local IFS=$'\n'
+		COMPREPLY=($(compgen -W $'debug\ninfo\nvery verbose' -- "$cur"))
+		[ ${#COMPREPLY[@]} -gt 0 ] && COMPREPLY=($(printf '%q\n' "${COMPREPLY[@]}"))
+		return
+		;;
+	--config)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return
+		;;
+	--dir)
+		COMPREPLY=($(compgen -d -- "$cur"))
+		return
+		;;
+	esac
+
+	COMPREPLY=($(compgen -W '--help --level -l --config --dir --debug' -- "$cur"))
+}
+
+complete -o default -F _myapp_completion myapp
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, loader.Flags{}.Completion(&out, "zsh", "myapp", &c))
+
+	assert.Equal(t, `#compdef myapp
+
+_myapp() {
+	_arguments \
+		'--help[show help]' \
+		'--level[log level]:level:(debug info very\ verbose)' \
+		'-l[log level]:level:(debug info very\ verbose)' \
+		'--config[config file]:config:_files' \
+		'--dir[]:dir:_files -/' \
+		'--debug[it'\''s \[debug\]]'
+}
+
+if [ "$funcstack[1]" = "_myapp" ]; then
+	_myapp "$@"
+else
+	compdef _myapp myapp
+fi
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, loader.Flags{}.Completion(&out, "fish", "myapp", &c))
+
+	assert.Equal(t, `complete -c myapp -l help -d 'show help'
+complete -c myapp -l level -s l -d 'log level' -x -a 'debug info very\\ verbose'
+complete -c myapp -l config -d 'config file' -r -F
+complete -c myapp -l dir -x -a '(__fish_complete_directories)'
+complete -c myapp -l debug -d 'it\'s [debug]'
+`, out.String())
+
+	err := loader.Flags{}.Completion(&out, "powershell", "myapp", &c)
+	assert.ErrorIs(t, err, loader.ErrUnsupportedShell)
+}
+
+func TestFlags_CompletionFlag(t *testing.T) {
+	var c completionConfig
+
+	var out bytes.Buffer
+
+	err := loader.Flags{Args: []string{"--completion", "fish"}, Output: &out}.Load("myapp", &c)
+
+	var completionErr *loader.CompletionError
+	require.ErrorAs(t, err, &completionErr)
+	assert.ErrorIs(t, err, flag.ErrHelp)
+	assert.Equal(t, "fish", completionErr.Shell)
+	assert.Contains(t, out.String(), "complete -c myapp -l level")
+
+	// completion flag is hidden in help
+	out.Reset()
+
+	err = loader.Flags{Args: []string{"-h"}, Output: &out}.Load("myapp", &c)
+	require.ErrorIs(t, err, flag.ErrHelp)
+	assert.NotContains(t, out.String(), "completion")
+}
+
+func TestFlags_CompletionSubcommand(t *testing.T) {
+	var c commandConfig
+
+	var out bytes.Buffer
+
+	require.NoError(t, loader.Flags{}.Completion(&out, "bash", "app", &c))
+
+	assert.Contains(t, out.String(), `
+		'/serve'|'/s') cmd='serve' ;;
+		'/db') cmd='db' ;;
+		'db/migrate') cmd='db migrate' ;;
+`)
+	assert.Contains(t, out.String(), `
+	'')
+		case "$prev" in
+		esac
+
+		COMPREPLY=($(compgen -W '--help --verbose -v serve s db' -- "$cur"))
+		;;
+	'serve')
+		case "$prev" in
+		--port|-p)
+			return
+			;;
+		esac
+
+		COMPREPLY=($(compgen -W '--help --port -p' -- "$cur"))
+		;;
+`)
+	assert.Contains(t, out.String(), `
+	'db migrate')
+		case "$prev" in
+		--steps)
+			return
+			;;
+		esac
+
+		COMPREPLY=($(compgen -W '--help --steps' -- "$cur"))
+`)
+
+	out.Reset()
+	require.NoError(t, loader.Flags{}.Completion(&out, "zsh", "app", &c))
+
+	assert.Contains(t, out.String(), `
+		'1: :((serve\:start\ the\ server s\:start\ the\ server db\:))' \
+		'*::arg:->args'
+
+	case $state in
+	args)
+		case $line[1] in
+		serve|s) _app_serve ;;
+		db) _app_db ;;
+		esac
+`)
+	assert.Contains(t, out.String(), `
+_app_serve() {
+	_arguments \
+		'--help[show help]' \
+		'--port[]:port:' \
+		'-p[]:port:'
+}
+`)
+	assert.Contains(t, out.String(), "_app_db_migrate() {\n")
+
+	out.Reset()
+	require.NoError(t, loader.Flags{}.Completion(&out, "fish", "app", &c))
+
+	assert.Equal(t, `complete -c app -l help -d 'show help'
+complete -c app -n 'not __fish_seen_subcommand_from serve s db' -l verbose -s v
+complete -c app -n 'not __fish_seen_subcommand_from serve s db' -f -a 'serve s' -d 'start the server'
+complete -c app -n 'not __fish_seen_subcommand_from serve s db' -f -a 'db'
+complete -c app -n '__fish_seen_subcommand_from serve s' -l port -s p -r
+complete -c app -n '__fish_seen_subcommand_from db; and not __fish_seen_subcommand_from migrate' -f -a 'migrate'
+complete -c app -n '__fish_seen_subcommand_from db; and __fish_seen_subcommand_from migrate' -l steps -r
+`, out.String())
+
+	// flag after a subcommand writes the script of the main command
+	out.Reset()
+
+	err := loader.Flags{Args: []string{"serve", "--completion", "fish"}, Output: &out}.Load("app", &c)
+	require.ErrorIs(t, err, flag.ErrHelp)
+	assert.Contains(t, out.String(), "complete -c app -n '__fish_seen_subcommand_from serve s' -l port")
+	assert.Contains(t, out.String(), "-l verbose")
+}
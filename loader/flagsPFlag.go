@@ -0,0 +1,20 @@
+package loader
+
+import "github.com/spf13/pflag"
+
+var _ FlagSet = PFlagSet{}
+
+// PFlagSet is a FlagSet adapter of *pflag.FlagSet, like the flags of a cobra command.
+//
+// Bool flags can be used without value, like '--debug'.
+type PFlagSet struct {
+	*pflag.FlagSet
+}
+
+// Var defines the flag with the shorthand.
+func (s PFlagSet) Var(value FlagValue, name, shorthand, usage string) {
+	f := s.FlagSet.VarPF(value, name, shorthand, usage)
+	if value.IsBoolFlag() {
+		f.NoOptDefVal = "true"
+	}
+}
@@ -0,0 +1,54 @@
+package loader_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+func TestPFlagSet(t *testing.T) {
+	set := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	set.SetOutput(io.Discard)
+
+	var c struct {
+		Name    string   `cmd:"name,n" usage:"name of the service"`
+		Port    int      `cmd:"port,listen!deprecated"`
+		Debug   bool     `cmd:"debug,d"`
+		Tags    []string `cmd:"tag"`
+		Verbose bool     `cmd:"v"`
+		Level   string   `cmd:"l"`
+	}
+
+	c.Name = "default"
+
+	binding, err := (loader.Flags{}).Bind(loader.PFlagSet{FlagSet: set}, &c)
+	require.NoError(t, err)
+
+	assert.Equal(t, "n", set.Lookup("name").Shorthand)
+	assert.Equal(t, "name of the service", set.Lookup("name").Usage)
+	assert.Equal(t, "default", set.Lookup("name").DefValue)
+	assert.Equal(t, "string", set.Lookup("name").Value.Type())
+	assert.Equal(t, "v", set.Lookup("v").Shorthand)
+
+	args := []string{"-n", "piet", "-d", "--listen", "8080", "--tag", "a", "--tag", "b", "-v", "-l", "debug"}
+	require.NoError(t, set.Parse(args))
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, binding.Load("app", &c))
+	}
+
+	assert.Equal(t, "piet", c.Name)
+	assert.True(t, c.Debug)
+	assert.Equal(t, 8080, c.Port)
+	assert.Equal(t, []string{"a", "b"}, c.Tags)
+	assert.True(t, c.Verbose)
+	assert.Equal(t, "debug", c.Level)
+
+	err = set.Parse([]string{"--port", "abc"})
+	assert.ErrorContains(t, err, `invalid argument "abc" for "--port" flag`)
+}
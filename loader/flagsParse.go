@@ -0,0 +1,136 @@
+package loader
+
+import (
+	"flag"
+	"strings"
+)
+
+// normalizeArgs converts GNU style arguments to the standard flag arguments.
+//
+// Returned flagArgs are parsed with flag.FlagSet, rest holds positional arguments
+// and unknown flags in Lenient mode in their original order, positional holds only positional arguments.
+//
+// Arguments after "--" are always positional, arguments after a subcommand are kept as is.
+func (l Flags) normalizeArgs(set *flag.FlagSet, args []string) (flagArgs, rest, positional []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			positional = append(positional, args[i+1:]...)
+
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			// arguments of the subcommand are parsed with its flags
+			if _, ok := findCommand(l.commands, arg); ok && len(positional) == 0 {
+				rest = append(rest, args[i:]...)
+				positional = append(positional, args[i:]...)
+
+				break
+			}
+
+			rest = append(rest, arg)
+			positional = append(positional, arg)
+
+			continue
+		}
+
+		single := !strings.HasPrefix(arg, "--")
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+
+		if fl := set.Lookup(name); fl != nil {
+			var consumed int
+
+			flagArgs, consumed = appendFlag(flagArgs, fl, value, hasValue, args[i+1:])
+			i += consumed
+
+			continue
+		}
+
+		if l.GNU {
+			// --no-feature
+			if fl := set.Lookup(strings.TrimPrefix(name, "no-")); fl != nil && fl.Name != name && isBoolFlag(fl) && !hasValue {
+				flagArgs = append(flagArgs, "-"+fl.Name+"=false")
+
+				continue
+			}
+
+			// -abc or -p8080
+			if single && !hasValue {
+				if bundled, consumed, ok := bundleFlags(set, name, args[i+1:]); ok {
+					flagArgs = append(flagArgs, bundled...)
+					i += consumed
+
+					continue
+				}
+			}
+		}
+
+		// help is handled in flag.FlagSet
+		if l.Lenient && name != "h" && name != "help" {
+			rest = append(rest, arg)
+
+			continue
+		}
+
+		// flag.FlagSet returns the error
+		flagArgs = append(flagArgs, arg)
+	}
+
+	return flagArgs, rest, positional
+}
+
+// appendFlag adds the flag with its value, value is taken from next arguments if needed.
+//
+// Returns the number of consumed next arguments.
+func appendFlag(flagArgs []string, fl *flag.Flag, value string, hasValue bool, next []string) ([]string, int) {
+	switch {
+	case hasValue:
+		return append(flagArgs, "-"+fl.Name+"="+value), 0
+	case isBoolFlag(fl):
+		return append(flagArgs, "-"+fl.Name), 0
+	case len(next) > 0:
+		return append(flagArgs, "-"+fl.Name+"="+next[0]), 1
+	default:
+		// flag.FlagSet returns missing argument error
+		return append(flagArgs, "-"+fl.Name), 0
+	}
+}
+
+// bundleFlags splits bundled short flags like "-abc" to "-a -b -c".
+//
+// Last flag can have a value like "-vp8080" or "-vp 8080".
+func bundleFlags(set *flag.FlagSet, name string, next []string) ([]string, int, bool) {
+	var flagArgs []string
+
+	for j := 0; j < len(name); j++ {
+		fl := set.Lookup(name[j : j+1])
+		if fl == nil {
+			return nil, 0, false
+		}
+
+		if isBoolFlag(fl) {
+			flagArgs = append(flagArgs, "-"+fl.Name)
+
+			continue
+		}
+
+		if value := name[j+1:]; value != "" {
+			return append(flagArgs, "-"+fl.Name+"="+value), 0, true
+		}
+
+		flagArgs, consumed := appendFlag(flagArgs, fl, "", false, next)
+
+		return flagArgs, consumed, true
+	}
+
+	return flagArgs, 0, true
+}
+
+func isBoolFlag(fl *flag.Flag) bool {
+	b, ok := fl.Value.(interface{ IsBoolFlag() bool })
+
+	return ok && b.IsBoolFlag()
+}
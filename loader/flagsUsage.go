@@ -0,0 +1,250 @@
+package loader
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/worldline-go/igconfig/internal"
+)
+
+// FlagUsageTags are the tags to get usage text of the flags, first found tag is used.
+var FlagUsageTags = []string{"usage", "desc"}
+
+// HelpError is returned from Flags when help is requested with -h or --help.
+//
+// It wraps flag.ErrHelp, so errors.Is(err, flag.ErrHelp) can be used to exit without failure.
+type HelpError struct {
+	// Usage is the rendered help text.
+	Usage string
+}
+
+func (e *HelpError) Error() string {
+	return "flags: help requested"
+}
+
+func (e *HelpError) Unwrap() error {
+	return flag.ErrHelp
+}
+
+// flagUsage holds information of the fields to render help text.
+type flagUsage struct {
+	fields   []*flagField
+	byName   map[string]*flagField
+	commands []flagCommand
+	// args are the positional arguments shown in the usage line.
+	args string
+	// envPrefix is the env name of the subcommand, env names of its fields start with it.
+	envPrefix string
+}
+
+type flagField struct {
+	names    []internal.FieldName
+	group    string
+	usage    string
+	env      string
+	typeName string
+	defValue string
+	isStruct bool
+	// values and complete are used in completion scripts.
+	values   []string
+	complete string
+}
+
+func newFlagUsage() *flagUsage {
+	return &flagUsage{byName: make(map[string]*flagField)}
+}
+
+// addField records the field, it is called from FieldNameFunc.
+func (u *flagUsage) addField(outer string, names []internal.FieldName, field reflect.StructField) {
+	name := names[0].Name
+	if name == internal.SkipFieldTagValue {
+		return
+	}
+
+	outerEnv := u.envPrefix
+	if outerField, ok := u.byName[outer]; ok {
+		outerEnv = outerField.env
+	}
+
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	f := &flagField{
+		names:    names,
+		group:    outer,
+		env:      Env{}.FieldNameFunc(outerEnv, field),
+		isStruct: internal.IsStruct(typ),
+	}
+
+	f.values, f.complete = completionRules(field.Tag.Get(CompletionValidateTag))
+
+	for _, tag := range FlagUsageTags {
+		if usage, ok := field.Tag.Lookup(tag); ok {
+			f.usage = usage
+
+			break
+		}
+	}
+
+	u.fields = append(u.fields, f)
+	u.byName[name] = f
+}
+
+// setValue records the type and default value of the flag, it is called when flag is added.
+func (u *flagUsage) setValue(name string, field reflect.Value, fl *flag.Flag) {
+	f, ok := u.byName[name]
+	if !ok || fl == nil {
+		return
+	}
+
+	f.typeName = flagTypeName(field.Type())
+
+	if !field.IsZero() && !isDecodeKind(field.Kind()) {
+		f.defValue = fl.DefValue
+	}
+}
+
+// Render writes help text, flags are grouped by nested structs.
+func (u *flagUsage) Render(w io.Writer, appName string) {
+	var args string
+	if u.args != "" {
+		args = " " + u.args
+	}
+
+	if appName == "" {
+		fmt.Fprintf(w, "Usage:%s\n", args)
+	} else {
+		fmt.Fprintf(w, "Usage of %s%s:\n", appName, args)
+	}
+
+	u.renderGroup(w, "")
+
+	if len(u.commands) > 0 {
+		fmt.Fprintf(w, "\nCommands:\n")
+
+		for _, command := range u.commands {
+			fmt.Fprintf(w, "  %s\n", strings.Join(command.names, ", "))
+
+			if command.usage != "" {
+				fmt.Fprintf(w, "    \t%s\n", command.usage)
+			}
+		}
+	}
+}
+
+func (u *flagUsage) renderGroup(w io.Writer, group string) {
+	var structs []*flagField
+
+	for _, f := range u.fields {
+		if f.group != group {
+			continue
+		}
+
+		if f.isStruct {
+			structs = append(structs, f)
+		}
+
+		// fields without flag, like unsupported types or embedded structs
+		if f.typeName == "" {
+			continue
+		}
+
+		u.renderField(w, f)
+	}
+
+	for _, f := range structs {
+		title := f.usage
+		if title == "" {
+			title = f.names[0].Name
+		}
+
+		fmt.Fprintf(w, "\n%s:\n", title)
+		u.renderGroup(w, f.names[0].Name)
+	}
+}
+
+func (u *flagUsage) renderField(w io.Writer, f *flagField) {
+	names := make([]string, 0, len(f.names))
+
+	for _, name := range f.names {
+		if !name.Deprecated {
+			names = append(names, "-"+name.Name)
+		}
+	}
+
+	line := "  " + strings.Join(names, ", ")
+	if f.typeName != "bool" {
+		line += " " + f.typeName
+	}
+
+	var details []string
+	if f.usage != "" {
+		details = append(details, f.usage)
+	}
+
+	if f.defValue != "" {
+		details = append(details, fmt.Sprintf("(default %s)", quoteDefault(f.typeName, f.defValue)))
+	}
+
+	if f.env != "" && f.env != internal.SkipFieldTagValue {
+		details = append(details, "[$"+f.env+"]")
+	}
+
+	fmt.Fprintln(w, line)
+
+	if len(details) > 0 {
+		fmt.Fprintf(w, "    \t%s\n", strings.ReplaceAll(strings.Join(details, " "), "\n", "\n    \t"))
+	}
+}
+
+func quoteDefault(typeName, value string) string {
+	if typeName == "string" {
+		return fmt.Sprintf("%q", value)
+	}
+
+	return value
+}
+
+// flagTypeName returns the type name shown in help text.
+func flagTypeName(typ reflect.Type) string {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == reflect.TypeOf(time.Duration(0)):
+		return "duration"
+	case typ == internal.TimeType:
+		return "time"
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		return "json"
+	case reflect.Map:
+		return "key=value"
+	case reflect.Slice, reflect.Array:
+		return "[]" + flagTypeName(typ.Elem())
+	default:
+		return typ.Kind().String()
+	}
+}
+
+// fieldUsage returns the usage text of the field.
+func (u *flagUsage) fieldUsage(name string) string {
+	if u == nil {
+		return ""
+	}
+
+	if f, ok := u.byName[name]; ok {
+		return f.usage
+	}
+
+	return ""
+}
@@ -1,10 +1,15 @@
 package loader_test
 
 import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -66,6 +71,28 @@ func TestFlags_FieldNameFunc(t *testing.T) {
 	require.Equal(t, []string{"ones", "2", "-", "in", "in-f"}, res)
 }
 
+func TestFlags_Aliases(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := zerolog.New(&buf).WithContext(context.Background())
+
+	var c struct {
+		Name string `cmd:"name,n"`
+		Port int    `cmd:"port,listen!deprecated"`
+	}
+
+	args := []string{"-n", "Piet", "--listen", "1234"}
+
+	require.NoError(t, (loader.Flags{Args: args}).LoadWithContext(ctx, "", &c))
+
+	assert.Equal(t, "Piet", c.Name)
+	assert.Equal(t, 1234, c.Port)
+	assert.Contains(t, buf.String(), `"flag":"listen","use":"port"`)
+
+	require.NoError(t, (loader.Flags{}).LoadSlice(&c, []string{"-port", "80", "-listen", "90"}))
+	assert.Equal(t, 80, c.Port)
+}
+
 func fieldCapture(nameFunc internal.FieldNameFunc, namesSlice *[]string) internal.FieldNameFunc {
 	return func(outerName string, currentField reflect.StructField) string {
 		name := nameFunc(outerName, currentField)
@@ -75,3 +102,230 @@ func fieldCapture(nameFunc internal.FieldNameFunc, namesSlice *[]string) interna
 		return name
 	}
 }
+
+func TestFlags_Help(t *testing.T) {
+	type config struct {
+		Name  string        `cmd:"name,n,nm!deprecated" usage:"name of the service" default:"app"`
+		Port  int           `cmd:"port" desc:"port to listen"`
+		Debug bool          `usage:"enable debug logs"`
+		Wait  time.Duration `env:"-"`
+		DB    struct {
+			Host string `usage:"database host"`
+			Pass string `env:"PASSWORD"`
+		} `cmd:"db" usage:"Database settings"`
+	}
+
+	c := config{Name: "app"}
+
+	var out bytes.Buffer
+
+	err := loader.Flags{Args: []string{"--help"}, Output: &out}.LoadWithContext(context.Background(), "myapp", &c)
+
+	var helpErr *loader.HelpError
+	require.ErrorAs(t, err, &helpErr)
+	assert.ErrorIs(t, err, flag.ErrHelp)
+
+	expected := `Usage of myapp:
+  -name, -n string
+    	name of the service (default "app") [$NAME]
+  -port int
+    	port to listen [$PORT]
+  -debug
+    	enable debug logs [$DEBUG]
+  -wait duration
+  -db json
+    	Database settings [$DB]
+
+Database settings:
+  -db-host string
+    	database host [$DB_HOST]
+  -db-pass string
+    	[$DB_PASSWORD]
+`
+	assert.Equal(t, expected, out.String())
+	assert.Equal(t, expected, helpErr.Usage)
+
+	out.Reset()
+
+	err = loader.Flags{Args: []string{"-h"}, Output: &out, NoUsage: true}.Load("myapp", &c)
+	require.ErrorAs(t, err, &helpErr)
+	assert.Empty(t, out.String())
+	assert.Equal(t, expected, helpErr.Usage)
+}
+
+func TestFlags_ShortAliases(t *testing.T) {
+	var c struct {
+		Server struct {
+			Port int `cmd:"port,p"`
+		}
+		Verbose bool `cmd:"verbose,v"`
+	}
+
+	require.NoError(t, (loader.Flags{}).LoadSlice(&c, []string{"-p", "8080", "-v"}))
+	assert.Equal(t, 8080, c.Server.Port)
+	assert.True(t, c.Verbose)
+
+	var out bytes.Buffer
+
+	err := (loader.Flags{Output: &out}).LoadSlice(&c, []string{"-h"})
+	require.ErrorIs(t, err, flag.ErrHelp)
+	assert.Contains(t, out.String(), "  -server-port, -p int\n")
+
+	// one letter names are also conflicts
+	var conflict struct {
+		Port    int  `cmd:"port,p"`
+		Profile bool `cmd:"profile,p"`
+	}
+
+	err = (loader.Flags{}).LoadSlice(&conflict, []string{"-p", "1"})
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+	assert.ErrorContains(t, err, `-p of "profile" is already defined`)
+	assert.Zero(t, conflict.Port)
+	assert.False(t, conflict.Profile)
+
+	var long struct {
+		Port int `cmd:"port"`
+		Addr int `cmd:"addr,port"`
+	}
+
+	err = (loader.Flags{}).LoadSlice(&long, []string{"--port", "1"})
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+	assert.ErrorContains(t, err, `-port of "addr" is already defined`)
+}
+
+func TestFlags_ShortAliasesReusedStruct(t *testing.T) {
+	type db struct {
+		Host string `cmd:"host,h"`
+		Port int    `cmd:"port,p"`
+	}
+
+	var c struct {
+		Primary db
+		Replica db
+	}
+
+	// "h" is the help flag
+	err := (loader.Flags{}).LoadSlice(&c, []string{"--primary-host", "a"})
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+	assert.ErrorContains(t, err, `-h of "primary-host" is the help flag`)
+	assert.Empty(t, c.Primary.Host)
+
+	var reused struct {
+		Primary struct {
+			Port int `cmd:"port,p"`
+		}
+		Replica struct {
+			Port int `cmd:"port,p"`
+		}
+	}
+
+	err = (loader.Flags{}).LoadSlice(&reused, []string{"-p", "5"})
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+	assert.ErrorContains(t, err, `-p of "replica-port" is already defined`)
+	assert.Zero(t, reused.Primary.Port)
+	assert.Zero(t, reused.Replica.Port)
+
+	var short struct {
+		Name string `cmd:"h"`
+	}
+
+	err = (loader.Flags{}).LoadSlice(&short, []string{"-h", "x"})
+	assert.ErrorIs(t, err, loader.ErrFlagConflict)
+}
+
+func TestFlags_Collections(t *testing.T) {
+	var c struct {
+		Tags   []string
+		Ports  []int
+		Waits  []time.Duration
+		Labels map[string]string `cmd:"label"`
+		Start  time.Time
+	}
+
+	c.Tags = []string{"default"}
+
+	args := []string{
+		"--tags", "a", "--tags", "b,c",
+		"--ports", "80", "--ports", "443",
+		"--waits", "1s",
+		"--label", "team=core", "--label", "env=prod",
+		"--start", "2024-01-02T03:04:05Z",
+	}
+
+	require.NoError(t, (loader.Flags{}).LoadSlice(&c, args))
+
+	assert.Equal(t, []string{"a", "b", "c"}, c.Tags)
+	assert.Equal(t, []int{80, 443}, c.Ports)
+	assert.Equal(t, []time.Duration{time.Second}, c.Waits)
+	assert.Equal(t, map[string]string{"team": "core", "env": "prod"}, c.Labels)
+	assert.True(t, c.Start.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	// not given flags keep the value
+	require.NoError(t, (loader.Flags{}).LoadSlice(&c, []string{"--ports", "1"}))
+	assert.Equal(t, []string{"a", "b", "c"}, c.Tags)
+	assert.Equal(t, []int{1}, c.Ports)
+}
+
+func TestFlags_CollectionsAliases(t *testing.T) {
+	var c struct {
+		Tags   []string          `cmd:"tag,t"`
+		Labels map[string]string `cmd:"label,l"`
+	}
+
+	c.Tags = []string{"default"}
+
+	args := []string{"--tag", "a", "-t", "b", "--tag", "c", "-l", "team=core", "--label", "env=prod"}
+	require.NoError(t, (loader.Flags{}).LoadSlice(&c, args))
+
+	assert.Equal(t, []string{"a", "b", "c"}, c.Tags)
+	assert.Equal(t, map[string]string{"team": "core", "env": "prod"}, c.Labels)
+}
+
+func TestFlags_GNU(t *testing.T) {
+	type config struct {
+		All     bool   `cmd:"all,a"`
+		Verbose bool   `cmd:"verbose,v"`
+		Cache   bool   `cmd:"cache"`
+		Port    int    `cmd:"port,p"`
+		Name    string `cmd:"name,n"`
+	}
+
+	var rest []string
+
+	c := config{Cache: true}
+
+	args := []string{"serve", "-av", "--no-cache", "-p8080", "file.txt", "--name", "x", "--", "-v"}
+
+	require.NoError(t, (loader.Flags{GNU: true, Rest: &rest}).LoadSlice(&c, args))
+
+	assert.Equal(t, config{All: true, Verbose: true, Cache: false, Port: 8080, Name: "x"}, c)
+	assert.Equal(t, []string{"serve", "file.txt", "-v"}, rest)
+
+	c = config{}
+
+	require.NoError(t, (loader.Flags{GNU: true}).LoadSlice(&c, []string{"-vp", "90", "-name", "long"}))
+	assert.Equal(t, config{Verbose: true, Port: 90, Name: "long"}, c)
+
+	err := (loader.Flags{GNU: true, NoUsage: true, Output: io.Discard}).LoadSlice(&c, []string{"-x"})
+	assert.EqualError(t, err, "flags parsing error: flag provided but not defined: -x")
+}
+
+func TestFlags_Lenient(t *testing.T) {
+	var c struct {
+		Port int `cmd:"port"`
+	}
+
+	var rest []string
+
+	args := []string{"run", "--unknown", "value", "--port", "80", "-x=1", "--", "--port", "90"}
+
+	require.NoError(t, (loader.Flags{Lenient: true, Rest: &rest}).LoadSlice(&c, args))
+
+	assert.Equal(t, 80, c.Port)
+	assert.Equal(t, []string{"run", "--unknown", "value", "-x=1", "--port", "90"}, rest)
+
+	// standard parsing stops at the first positional argument
+	require.NoError(t, (loader.Flags{Rest: &rest}).LoadSlice(&c, []string{"--port", "1", "run", "--port", "2"}))
+	assert.Equal(t, 1, c.Port)
+	assert.Equal(t, []string{"run", "--port", "2"}, rest)
+}
@@ -0,0 +1,330 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/worldline-go/igconfig/codec"
+)
+
+// HTTPTag is a tag used to identify field name.
+var HTTPTag = "cfg"
+
+// HTTPPollInterval is the default interval to check changes in DynamicValue.
+var HTTPPollInterval = 30 * time.Second
+
+// HTTPTimeout is the default timeout of the requests when Client is not set.
+var HTTPTimeout = 10 * time.Second
+
+// HTTPContentTypes maps content type of the response to the file extension in FileDecoders.
+var HTTPContentTypes = map[string]string{
+	"application/json":   ".json",
+	"application/yaml":   ".yaml",
+	"application/x-yaml": ".yaml",
+	"text/yaml":          ".yaml",
+	"text/x-yaml":        ".yaml",
+	"application/toml":   ".toml",
+	"application/json5":  ".json5",
+}
+
+var (
+	_ Loader        = (*HTTP)(nil)
+	_ DynamicValuer = (*HTTP)(nil)
+)
+
+// HTTP loads configuration from a remote file over HTTP(S).
+//
+// File is fetched from `<BaseURL>/<appName><suffix>` where suffixes are tried in ConfFileSuffixes order,
+// or from URL if it is set.
+// Decoder is chosen with Content-Type of the response (see HTTPContentTypes) or extension of the URL.
+//
+// Responses are cached with ETag and Last-Modified headers, not modified responses use the cached body.
+//
+// Example usage:
+//
+//	httpLoader := &loader.HTTP{BaseURL: "https://config.example.com/configs", Token: "..."}
+//	err := httpLoader.Load("adm0001s", &config)
+type HTTP struct {
+	// BaseURL is the base address to fetch the file with appName.
+	BaseURL string
+	// URL is an explicit address of the file, BaseURL is not used if it is set.
+	URL string
+	// Token is a bearer token for Authorization header.
+	Token string
+	// Username and Password for basic authentication.
+	Username string
+	Password string
+	// Header is additional headers for requests.
+	Header http.Header
+	// TLSConfig is used when Client is not set.
+	TLSConfig *tls.Config
+	// Client to make requests, default is a client with TLSConfig and Timeout.
+	Client *http.Client
+	// Timeout of the requests when Client is not set, default is HTTPTimeout.
+	Timeout time.Duration
+	// PollInterval to check changes in DynamicValue, default is HTTPPollInterval.
+	PollInterval time.Duration
+
+	mutex sync.Mutex
+	cache map[string]httpCache
+}
+
+type httpCache struct {
+	etag         string
+	lastModified string
+	contentType  string
+	body         []byte
+}
+
+// httpResponse is the result of the fetch.
+type httpResponse struct {
+	url         string
+	contentType string
+	body        []byte
+	modified    bool
+}
+
+// LoadWithContext fetches the file and decodes it into 'to' struct.
+func (l *HTTP) LoadWithContext(ctx context.Context, appName string, to interface{}) error {
+	resp, err := l.fetchApp(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	decoder, err := l.decoder(resp)
+	if err != nil {
+		return err
+	}
+
+	if err := codec.LoadReaderWithSource(ctx, bytes.NewReader(resp.body), to, decoder, HTTPTag, resp.url); err != nil {
+		return fmt.Errorf("HTTP.LoadWithContext error: %w", err)
+	}
+
+	return nil
+}
+
+// Load is just same as LoadWithContext without context.
+func (l *HTTP) Load(appName string, to interface{}) error {
+	return l.LoadWithContext(context.Background(), appName, to)
+}
+
+// DynamicValue polls the file of the appName in PollInterval and sends the body if it is changed.
+//
+// First value is sent immediately. Channel is closed when ctx is done.
+// Fetch errors are logged and polling continues.
+func (l *HTTP) DynamicValue(ctx context.Context, appName string) (<-chan []byte, error) {
+	resp, err := l.fetchApp(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := l.PollInterval
+	if interval <= 0 {
+		interval = HTTPPollInterval
+	}
+
+	vChannel := make(chan []byte)
+
+	go func() {
+		defer close(vChannel)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		body := resp.body
+
+		for {
+			if body != nil {
+				select {
+				case vChannel <- body:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			newResp, err := l.fetch(ctx, resp.url)
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("url", resp.url).Msg("http polling error")
+
+				body = nil
+
+				continue
+			}
+
+			body = nil
+			if newResp.modified {
+				body = newResp.body
+			}
+		}
+	}()
+
+	return vChannel, nil
+}
+
+// fetchApp fetches URL or tries BaseURL with suffixes.
+func (l *HTTP) fetchApp(ctx context.Context, appName string) (*httpResponse, error) {
+	if l.URL != "" {
+		return l.fetch(ctx, l.URL)
+	}
+
+	if l.BaseURL == "" {
+		return nil, fmt.Errorf("HTTP URL or BaseURL not set, err: %w", ErrNoClient)
+	}
+
+	for _, suffix := range ConfFileSuffixes {
+		u, err := url.JoinPath(l.BaseURL, appName+suffix)
+		if err != nil {
+			return nil, fmt.Errorf("HTTP url: %w", err)
+		}
+
+		resp, err := l.fetch(ctx, u)
+		if errors.Is(err, ErrNoConfFile) {
+			continue
+		}
+
+		return resp, err
+	}
+
+	return nil, fmt.Errorf("%w: %s not found in %s", ErrNoConfFile, appName, l.BaseURL)
+}
+
+// fetch requests the URL with cache headers.
+func (l *HTTP) fetch(ctx context.Context, u string) (*httpResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request: %w", err)
+	}
+
+	for k, v := range l.Header {
+		req.Header[k] = v
+	}
+
+	switch {
+	case l.Token != "":
+		req.Header.Set("Authorization", "Bearer "+l.Token)
+	case l.Username != "":
+		req.SetBasicAuth(l.Username, l.Password)
+	}
+
+	l.mutex.Lock()
+	cached, isCached := l.cache[u]
+	l.mutex.Unlock()
+
+	if isCached {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := l.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request %s: %w", u, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && isCached:
+		return &httpResponse{url: u, contentType: cached.contentType, body: cached.body}, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNoConfFile, u)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("HTTP request %s: unexpected status %s", u, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP read body %s: %w", u, err)
+	}
+
+	modified := !isCached || !bytes.Equal(body, cached.body)
+
+	l.mutex.Lock()
+	if l.cache == nil {
+		l.cache = make(map[string]httpCache)
+	}
+
+	l.cache[u] = httpCache{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		contentType:  resp.Header.Get("Content-Type"),
+		body:         body,
+	}
+	l.mutex.Unlock()
+
+	return &httpResponse{
+		url:         u,
+		contentType: resp.Header.Get("Content-Type"),
+		body:        body,
+		modified:    modified,
+	}, nil
+}
+
+func (l *HTTP) client() *http.Client {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.Client != nil {
+		return l.Client
+	}
+
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = HTTPTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = l.TLSConfig
+
+	// a hung server should not block the loading or the polling forever
+	l.Client = &http.Client{Transport: transport, Timeout: timeout}
+
+	return l.Client
+}
+
+// decoder chooses decoder with the content type or the extension of the URL.
+func (l *HTTP) decoder(resp *httpResponse) (codec.Decoder, error) {
+	if mediaType, _, err := mime.ParseMediaType(resp.contentType); err == nil {
+		if ext, ok := HTTPContentTypes[mediaType]; ok {
+			if decoder, ok := FileDecoders[ext]; ok {
+				return decoder, nil
+			}
+		}
+	}
+
+	u, err := url.Parse(resp.url)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP url: %w", err)
+	}
+
+	ext := path.Ext(u.Path)
+
+	decoder, ok := FileDecoders[strings.ToLower(ext)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoDecoder, resp.url)
+	}
+
+	return decoder, nil
+}
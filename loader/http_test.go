@@ -0,0 +1,123 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_Load(t *testing.T) {
+	var notModified int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if r.URL.Path != "/configs/test.json" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"field_1": "one", "inner": {"field_2": "two"}}`))
+	}))
+	defer server.Close()
+
+	l := &HTTP{BaseURL: server.URL + "/configs", Token: "secret"}
+
+	var s testStruct
+	require.NoError(t, l.Load("test", &s))
+	assert.Equal(t, "one", s.Field1)
+	assert.Equal(t, "two", s.Inner.Field2)
+
+	// second load uses the cache
+	s = testStruct{}
+	require.NoError(t, l.Load("test", &s))
+	assert.Equal(t, "one", s.Field1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+
+	err := (&HTTP{BaseURL: server.URL + "/configs", Token: "secret"}).Load("missing", &s)
+	assert.True(t, errors.Is(err, ErrNoConfFile), err)
+
+	err = (&HTTP{}).Load("test", &s)
+	assert.True(t, errors.Is(err, ErrNoClient), err)
+}
+
+func TestHTTP_Timeout(t *testing.T) {
+	done := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	var s testStruct
+
+	start := time.Now()
+
+	err := (&HTTP{URL: server.URL + "/test.json", Timeout: 50 * time.Millisecond}).Load("test", &s)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	assert.Equal(t, HTTPTimeout, (&HTTP{}).client().Timeout)
+}
+
+func TestHTTP_DynamicValue(t *testing.T) {
+	var version int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if atomic.LoadInt32(&version) == 0 {
+			_, _ = w.Write([]byte(`value: 1`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`value: 2`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := &HTTP{URL: server.URL + "/app.yaml", Username: "user", Password: "pass", PollInterval: 10 * time.Millisecond}
+
+	ch, err := l.DynamicValue(ctx, "app")
+	require.NoError(t, err)
+
+	assert.Equal(t, "value: 1", string(<-ch))
+
+	atomic.StoreInt32(&version, 1)
+
+	assert.Equal(t, "value: 2", string(<-ch))
+
+	cancel()
+
+	for range ch {
+	}
+}
@@ -0,0 +1,199 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/worldline-go/igconfig/codec"
+	"github.com/worldline-go/igconfig/internal"
+)
+
+var (
+	// FileIncludeKey is a key in the config file to include other files.
+	// Value could be a file name or list of file names.
+	//
+	// Set it to empty string to disable include directive.
+	FileIncludeKey = "$include"
+	// FileExtendsKey is a key in the config file to extend a base file.
+	//
+	// Set it to empty string to disable extends directive.
+	FileExtendsKey = "extends"
+	// FileIncludeYAMLTag is a YAML tag to include a file in place of the value.
+	//
+	// Set it to empty string to disable YAML tag.
+	FileIncludeYAMLTag = "!include"
+)
+
+// ErrIncludeCycle is returned when included files create a cycle.
+var ErrIncludeCycle = errors.New("include cycle detected")
+
+// readFile reads the file to a map and resolves include and extends directives.
+//
+// Included files are resolved relative to the including file.
+// Order of the merge is extends, includes and the file itself, so file's own values have priority.
+//
+// Stack holds absolute paths of the files that are currently including, used for cycle detection.
+func (l File) readFile(ctx context.Context, fileName string, stack []string) (map[string]interface{}, error) {
+	absName, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("file loader: %w", err)
+	}
+
+	for _, s := range stack {
+		if s == absName {
+			return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(append(stack, absName), " -> "))
+		}
+	}
+
+	stack = append(stack[:len(stack):len(stack)], absName)
+
+	configType := filepath.Ext(fileName)
+
+	decoder, ok := FileDecoders[configType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoDecoder, configType)
+	}
+
+	if yamlDecoder, ok := decoder.(codec.YAML); ok && FileIncludeYAMLTag != "" {
+		tags := make(map[string]codec.YAMLTagFunc, len(yamlDecoder.Tags)+1)
+		for k, v := range yamlDecoder.Tags {
+			tags[k] = v
+		}
+
+		tags[FileIncludeYAMLTag] = l.yamlInclude(ctx, absName, stack)
+		yamlDecoder.Tags = tags
+		decoder = yamlDecoder
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("file loader: %w", err)
+	}
+
+	mapping, err := codec.DecodeMap(ctx, bytes.NewReader(data), decoder)
+	if err != nil {
+		return nil, codec.NewDecodeError(fileName, data, decoder, err)
+	}
+
+	return l.resolveIncludes(ctx, absName, mapping, stack)
+}
+
+// resolveIncludes loads files in FileExtendsKey and FileIncludeKey and merges mapping on top of them.
+func (l File) resolveIncludes(ctx context.Context, fileName string, mapping map[string]interface{}, stack []string) (map[string]interface{}, error) {
+	var includes []string
+
+	for _, key := range []string{FileExtendsKey, FileIncludeKey} {
+		if key == "" {
+			continue
+		}
+
+		v, ok := mapping[key]
+		if !ok {
+			continue
+		}
+
+		delete(mapping, key)
+
+		names, err := includeNames(v)
+		if err != nil {
+			return nil, fmt.Errorf("file %s, key %q: %w", fileName, key, err)
+		}
+
+		includes = append(includes, names...)
+	}
+
+	if len(includes) == 0 {
+		return mapping, nil
+	}
+
+	result, err := l.readFiles(ctx, fileName, includes, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	return internal.MergeMaps(result, mapping), nil
+}
+
+// readFiles reads and merges files in order, names are relative to the fileName.
+func (l File) readFiles(ctx context.Context, fileName string, names []string, stack []string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+
+	for _, name := range names {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(fileName), name)
+		}
+
+		m, err := l.readFile(ctx, name, stack)
+		if err != nil {
+			return nil, err
+		}
+
+		result = internal.MergeMaps(result, m)
+	}
+
+	return result, nil
+}
+
+// yamlInclude returns YAML tag function to replace node with the included file content.
+func (l File) yamlInclude(ctx context.Context, fileName string, stack []string) codec.YAMLTagFunc {
+	return func(node *yaml.Node) error {
+		var names []string
+
+		switch node.Kind {
+		case yaml.ScalarNode:
+			names = []string{node.Value}
+		case yaml.SequenceNode:
+			for _, n := range node.Content {
+				names = append(names, n.Value)
+			}
+		default:
+			return fmt.Errorf("%s tag at line %d: value should be file name or list of file names", FileIncludeYAMLTag, node.Line)
+		}
+
+		m, err := l.readFiles(ctx, fileName, names, stack)
+		if err != nil {
+			return err
+		}
+
+		var newNode yaml.Node
+		if err := newNode.Encode(m); err != nil {
+			return fmt.Errorf("%s tag at line %d: %w", FileIncludeYAMLTag, node.Line, err)
+		}
+
+		*node = newNode
+
+		return nil
+	}
+}
+
+// includeNames converts directive value to a list of file names.
+func includeNames(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		names := make([]string, 0, len(v))
+
+		for _, n := range v {
+			name, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("file name should be string, got %T", n)
+			}
+
+			names = append(names, name)
+		}
+
+		return names, nil
+	case []string:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("value should be string or list of strings, got %T", v)
+	}
+}
@@ -0,0 +1,93 @@
+package loader_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+type includeConfig struct {
+	Name string `cfg:"name"`
+	Log  struct {
+		Level  string `cfg:"level"`
+		Format string `cfg:"format"`
+	} `cfg:"log"`
+	DB struct {
+		Host string `cfg:"host"`
+		Port int    `cfg:"port"`
+	} `cfg:"db"`
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(data), 0o600))
+	}
+
+	return dir
+}
+
+func TestFile_LoadFile_Include(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"common/base.yaml": `
+name: base
+log:
+  level: info
+  format: json`,
+		"common/db.toml": `
+[db]
+host = "localhost"
+port = 5432`,
+		"app.yaml": `
+extends: common/base.yaml
+$include: [common/db.toml]
+name: app
+log:
+  level: debug`,
+	})
+
+	var c includeConfig
+	require.NoError(t, loader.File{}.LoadFile(filepath.Join(dir, "app.yaml"), &c))
+
+	assert.Equal(t, "app", c.Name)
+	assert.Equal(t, "debug", c.Log.Level)
+	assert.Equal(t, "json", c.Log.Format)
+	assert.Equal(t, "localhost", c.DB.Host)
+	assert.Equal(t, 5432, c.DB.Port)
+}
+
+func TestFile_LoadFile_IncludeYAMLTag(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"db.json": `{"host": "db.example.com", "port": 3306}`,
+		"app.yaml": `
+name: app
+db: !include db.json`,
+	})
+
+	var c includeConfig
+	require.NoError(t, loader.File{}.LoadFile(filepath.Join(dir, "app.yaml"), &c))
+
+	assert.Equal(t, "app", c.Name)
+	assert.Equal(t, "db.example.com", c.DB.Host)
+	assert.Equal(t, 3306, c.DB.Port)
+}
+
+func TestFile_LoadFile_IncludeCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml": `$include: b.yaml`,
+		"b.yaml": `extends: a.yaml`,
+	})
+
+	err := loader.File{}.LoadFile(filepath.Join(dir, "a.yaml"), &includeConfig{})
+	assert.True(t, errors.Is(err, loader.ErrIncludeCycle), err)
+}
@@ -0,0 +1,70 @@
+package loader
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/vault/api"
+
+	"github.com/worldline-go/igconfig/codec"
+)
+
+// VaultTransitMethod is the encryption method name for values encrypted with Vault transit engine.
+//
+// Format of the value is `ENC[VAULT_TRANSIT,key:<key_name>,data:vault:v1:...]`.
+const VaultTransitMethod = "VAULT_TRANSIT"
+
+// VaultTransitMountPath is the default mount path of the transit secret engine.
+var VaultTransitMountPath = "transit"
+
+var _ codec.Decrypter = VaultTransit{}
+
+// VaultWriter interface to write data to Vault, *api.Logical implements it.
+type VaultWriter interface {
+	WriteWithContext(ctx context.Context, path string, data map[string]interface{}) (*api.Secret, error)
+}
+
+// VaultTransit decrypts values with Vault transit engine.
+//
+// Register it to codec.Decrypters to decrypt values in files and Consul:
+//
+//	codec.Decrypters[loader.VaultTransitMethod] = loader.VaultTransit{Client: client.Logical()}
+type VaultTransit struct {
+	Client VaultWriter
+	// MountPath of the transit engine, default is VaultTransitMountPath.
+	MountPath string
+}
+
+// Decrypt decrypts the value with `<MountPath>/decrypt/<key>` endpoint.
+func (t VaultTransit) Decrypt(ctx context.Context, value codec.EncryptedValue) (string, error) {
+	if t.Client == nil {
+		return "", ErrNoClient
+	}
+
+	mountPath := t.MountPath
+	if mountPath == "" {
+		mountPath = VaultTransitMountPath
+	}
+
+	secret, err := t.Client.WriteWithContext(ctx, path.Join(mountPath, "decrypt", value.Fields["key"]), map[string]interface{}{
+		"ciphertext": value.Fields["data"],
+	})
+	if err != nil {
+		return "", fmt.Errorf("vault transit decrypt: %w", err)
+	}
+
+	if secret == nil {
+		return "", fmt.Errorf("vault transit decrypt: empty response")
+	}
+
+	plainBase64, _ := secret.Data["plaintext"].(string)
+
+	plain, err := base64.StdEncoding.DecodeString(plainBase64)
+	if err != nil {
+		return "", fmt.Errorf("vault transit decode plaintext: %w", err)
+	}
+
+	return string(plain), nil
+}
@@ -0,0 +1,46 @@
+package loader
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/codec"
+)
+
+type transitMock struct {
+	path string
+	data map[string]interface{}
+}
+
+func (m *transitMock) WriteWithContext(_ context.Context, path string, data map[string]interface{}) (*api.Secret, error) {
+	m.path, m.data = path, data
+
+	return &api.Secret{Data: map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString([]byte("decrypted")),
+	}}, nil
+}
+
+func TestVaultTransit_Decrypt(t *testing.T) {
+	mock := &transitMock{}
+
+	codec.Decrypters[VaultTransitMethod] = VaultTransit{Client: mock}
+	defer delete(codec.Decrypters, VaultTransitMethod)
+
+	var c struct {
+		Password string `cfg:"password"`
+	}
+
+	require.NoError(t, codec.LoadReaderWithDecoder(
+		strings.NewReader(`password: ENC[VAULT_TRANSIT,key:app,data:vault:v1:abcd]`), &c, codec.YAML{}, ConsulTag,
+	))
+
+	assert.Equal(t, "decrypted", c.Password)
+	assert.Equal(t, "transit/decrypt/app", mock.path)
+	assert.Equal(t, map[string]interface{}{"ciphertext": "vault:v1:abcd"}, mock.data)
+}
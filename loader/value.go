@@ -0,0 +1,77 @@
+package loader
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/worldline-go/igconfig/codec"
+	"github.com/worldline-go/igconfig/internal"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setValueString sets the field with internal.SetReflectValueString.
+//
+// Struct, map and slice fields are decoded from JSON or YAML flow values, like '{"rps":100,"burst":20}',
+// with codec.MapDecoder so `cfg` names and decode hooks are same with the other loaders.
+func setValueString(fieldName, v string, field reflect.Value) error {
+	if field.Kind() == reflect.Ptr {
+		return internal.SetPointerValue(field, func(elem reflect.Value) error {
+			return setValueString(fieldName, v, elem)
+		})
+	}
+
+	if isDecodeValue(field, v) {
+		return decodeValueString(fieldName, v, field)
+	}
+
+	return internal.SetReflectValueString(fieldName, v, field)
+}
+
+// isDecodeValue returns true if the value should be decoded for the field.
+//
+// Slices are decoded if value starts with '[', so comma separated values still work.
+// Structs and maps are decoded if value starts with '{' and there is no custom setter or encoding.TextUnmarshaler.
+func isDecodeValue(field reflect.Value, v string) bool {
+	v = strings.TrimSpace(v)
+	field = reflect.Indirect(field)
+
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array:
+		return strings.HasPrefix(v, "[")
+	case reflect.Struct, reflect.Map:
+		return strings.HasPrefix(v, "{") &&
+			internal.GetCustomSetter(field.Type()) == nil &&
+			!internal.TypeImplementsInterface(field, textUnmarshalerType).IsValid()
+	default:
+		return false
+	}
+}
+
+// decodeValueString decodes JSON or YAML flow value into the field.
+func decodeValueString(fieldName, v string, field reflect.Value) error {
+	var data interface{}
+	if err := yaml.Unmarshal([]byte(v), &data); err != nil {
+		return fmt.Errorf("val %q decode: %w", fieldName, err)
+	}
+
+	field = reflect.Indirect(field)
+
+	// decode to a new value to not merge with the previous slice or map values
+	out := reflect.New(field.Type())
+	if field.Kind() == reflect.Struct {
+		out.Elem().Set(field)
+	}
+
+	if err := codec.MapDecoder(data, out.Interface(), internal.DefaultConfigTag); err != nil {
+		return fmt.Errorf("val %q decode: %w", fieldName, err)
+	}
+
+	field.Set(out.Elem())
+
+	return nil
+}
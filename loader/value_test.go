@@ -0,0 +1,70 @@
+package loader_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/loader"
+)
+
+type valueLimits struct {
+	RPS     int           `cfg:"rps"`
+	Burst   int           `cfg:"burst"`
+	Timeout time.Duration `cfg:"timeout"`
+}
+
+type valueConfig struct {
+	Limits  valueLimits
+	Weights map[string]float64
+	Ports   []int
+	Hosts   []string
+	Routes  []valueLimits
+}
+
+func TestEnv_DecodeValues(t *testing.T) {
+	t.Setenv("LIMITS", `{"rps":100,"burst":20,"timeout":"2s"}`)
+	t.Setenv("LIMITS_BURST", "30")
+	t.Setenv("WEIGHTS", `{a: 0.5, b: 1}`)
+	t.Setenv("PORTS", "[80, 443]")
+	t.Setenv("HOSTS", "a,b")
+	t.Setenv("ROUTES_0", `{"rps":5}`)
+
+	var c valueConfig
+
+	require.NoError(t, loader.Env{}.Load("", &c))
+
+	assert.Equal(t, valueConfig{
+		Limits:  valueLimits{RPS: 100, Burst: 30, Timeout: 2 * time.Second},
+		Weights: map[string]float64{"a": 0.5, "b": 1},
+		Ports:   []int{80, 443},
+		Hosts:   []string{"a", "b"},
+		Routes:  []valueLimits{{RPS: 5}},
+	}, c)
+}
+
+func TestFlags_DecodeValues(t *testing.T) {
+	args := []string{
+		"-limits", `{"rps":100,"timeout":"1m"}`,
+		"-limits-burst", "5",
+		"-weights", `{"a": 2}`,
+		"-ports", "[1,2]",
+		"-hosts", `["x","y"]`,
+	}
+
+	var c valueConfig
+
+	require.NoError(t, loader.Flags{}.LoadSlice(&c, args))
+
+	assert.Equal(t, valueConfig{
+		Limits:  valueLimits{RPS: 100, Burst: 5, Timeout: time.Minute},
+		Weights: map[string]float64{"a": 2},
+		Ports:   []int{1, 2},
+		Hosts:   []string{"x", "y"},
+	}, c)
+
+	err := loader.Flags{NoUsage: true}.LoadSlice(&c, []string{"-ports", "[a"})
+	assert.ErrorContains(t, err, `val "ports" decode`)
+}
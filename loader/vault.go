@@ -2,10 +2,13 @@ package loader
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
-	"os"
+	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 	"strings"
@@ -13,12 +16,13 @@ import (
 
 	"github.com/hashicorp/vault/api"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
 
 	"github.com/worldline-go/igconfig/codec"
 	"github.com/worldline-go/igconfig/internal"
 )
 
-// AdditionalPath is used to add additional path to the Vault path.
+// AdditionalPath is used to add additional path to the Vault path or Consul key.
 type AdditionalPath struct {
 	// Map to wrap values to a new map with this key.
 	// It could be / seperated to create a nested map [e.g. "foo/bar"].
@@ -30,6 +34,55 @@ type AdditionalPath struct {
 	InnerPath string
 }
 
+// parentPaths returns the parents of the appName from the top, like "a" and "a/b" for "a/b/c".
+func parentPaths(appName string) []AdditionalPath {
+	parts := strings.Split(strings.Trim(appName, "/"), "/")
+
+	paths := make([]AdditionalPath, 0, len(parts)-1)
+	for i := 1; i < len(parts); i++ {
+		paths = append(paths, AdditionalPath{Name: strings.Join(parts[:i], "/")})
+	}
+
+	return paths
+}
+
+// reformat gets the InnerPath of the data and wraps it with Map.
+func (p AdditionalPath) reformat(ctx context.Context, data map[string]interface{}) map[string]interface{} {
+	if p.InnerPath != "" {
+		maps := strings.Split(p.InnerPath, "/")
+
+		for _, m := range maps {
+			var ok bool
+			data, ok = data[m].(map[string]interface{})
+			if !ok {
+				log.Ctx(ctx).Warn().Str("key", m).Msg("can't find key in data, leaving empty")
+
+				break
+			}
+		}
+	}
+
+	if p.Map != "" {
+		maps := strings.Split(p.Map, "/")
+
+		mapDef := map[string]interface{}{}
+		mapRange := mapDef
+		for _, m := range maps {
+			if m == maps[len(maps)-1] {
+				mapRange[m] = data
+				break
+			}
+
+			mapRange[m] = map[string]interface{}{}
+			mapRange = mapRange[m].(map[string]interface{})
+		}
+
+		data = mapDef
+	}
+
+	return data
+}
+
 // VaultRoleIDEnv specifies the name of environment a variable that holds Vault role id to authenticate with.
 const VaultRoleIDEnv = "VAULT_ROLE_ID"
 
@@ -89,6 +142,11 @@ type AuthOption func(*api.Client) error
 //	// config is now populated from Vault.
 type Vault struct {
 	Client Vaulter
+	// Hierarchical loads the parents of the appName before it,
+	// like "payments" and "payments/settlement" for "payments/settlement/worker".
+	// Only the secret of a parent is read, secrets under it are not listed.
+	// Parents that cannot be read are skipped with a warning.
+	Hierarchical bool
 }
 
 // NewVaulter creates a new Vault client.
@@ -111,7 +169,7 @@ func NewVaulter(addr, token string) (Vaulter, error) {
 //
 // See NewVaulterFromClient for more information.
 func NewVaulterFromEnv(ctx context.Context) (Vaulter, error) {
-	vault, err := api.NewClient(api.DefaultConfig())
+	vault, err := vaultClient(internal.EnvSourceFromContext(ctx))
 	if err != nil || vault == nil {
 		return nil, err
 	}
@@ -119,16 +177,213 @@ func NewVaulterFromEnv(ctx context.Context) (Vaulter, error) {
 	return NewVaulterFromClient(ctx, vault)
 }
 
+// vaultClient creates a client with the variables read from the source.
+//
+// api.DefaultConfig and api.NewClient read the process environment, their values are replaced if source is not OSEnv.
+func vaultClient(source EnvSource) (*api.Client, error) {
+	if _, isOS := source.(OSEnv); isOS {
+		return api.NewClient(api.DefaultConfig())
+	}
+
+	config, err := vaultConfig(source)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// namespace is a header, so headers of the process environment are replaced first
+	headers, err := vaultHeaders(source)
+	if err != nil {
+		return nil, err
+	}
+
+	cl.SetHeaders(headers)
+	cl.ClearToken()
+
+	if token, ok := source.LookupEnv(api.EnvVaultToken); ok {
+		cl.SetToken(token)
+	}
+
+	if namespace, ok := source.LookupEnv(api.EnvVaultNamespace); ok {
+		cl.SetNamespace(namespace)
+	}
+
+	return cl, nil
+}
+
+// vaultConfig returns api.DefaultConfig() with the variables read from the source like api.Config.ReadEnvironment.
+func vaultConfig(source EnvSource) (*api.Config, error) {
+	getEnv := func(key string) string {
+		v, _ := source.LookupEnv(key)
+
+		return v
+	}
+
+	config := api.DefaultConfig()
+
+	// reset the values of the process environment
+	config.Error = nil
+	config.Address = api.DefaultAddress
+	config.AgentAddress = ""
+	config.MaxRetries = 2
+	config.Timeout = 60 * time.Second
+	config.SRVLookup = false
+	config.Limiter = nil
+	config.DisableRedirects = false
+	config.HttpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	transport, ok := config.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unsupported transport %T", config.HttpClient.Transport)
+	}
+
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if v := getEnv(api.EnvVaultAddress); v != "" {
+		config.Address = v
+	}
+
+	config.AgentAddress = getEnv(api.EnvVaultAgentAddr)
+
+	if v := getEnv(api.EnvVaultMaxRetries); v != "" {
+		maxRetries, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", api.EnvVaultMaxRetries, err)
+		}
+
+		config.MaxRetries = int(maxRetries)
+	}
+
+	if v := getEnv(api.EnvVaultClientTimeout); v != "" {
+		// value without unit is seconds
+		timeout, err := time.ParseDuration(v)
+		if seconds, errSeconds := strconv.Atoi(v); errSeconds == nil {
+			timeout, err = time.Duration(seconds)*time.Second, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", api.EnvVaultClientTimeout, err)
+		}
+
+		config.Timeout = timeout
+	}
+
+	if v := getEnv(api.EnvRateLimit); v != "" {
+		var limit float64
+
+		var burst int
+
+		if _, err := fmt.Sscanf(v, "%f:%d", &limit, &burst); err != nil {
+			if limit, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("parse %s: %w", api.EnvRateLimit, err)
+			}
+
+			burst = int(limit)
+		}
+
+		config.Limiter = rate.NewLimiter(rate.Limit(limit), burst)
+	}
+
+	for key, value := range map[string]*bool{
+		api.EnvVaultSRVLookup:        &config.SRVLookup,
+		api.EnvVaultDisableRedirects: &config.DisableRedirects,
+	} {
+		if v := getEnv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", key, err)
+			}
+
+			*value = b
+		}
+	}
+
+	// VAULT_PROXY_ADDR supersedes VAULT_HTTP_PROXY
+	proxy := getEnv(api.EnvHTTPProxy)
+	if v := getEnv(api.EnvVaultProxyAddr); v != "" {
+		proxy = v
+	}
+
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy address: %w", err)
+		}
+
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	tlsConfig := &api.TLSConfig{
+		CACert:        getEnv(api.EnvVaultCACert),
+		CACertBytes:   []byte(getEnv(api.EnvVaultCACertBytes)),
+		CAPath:        getEnv(api.EnvVaultCAPath),
+		ClientCert:    getEnv(api.EnvVaultClientCert),
+		ClientKey:     getEnv(api.EnvVaultClientKey),
+		TLSServerName: getEnv(api.EnvVaultTLSServerName),
+	}
+
+	if v := getEnv(api.EnvVaultSkipVerify); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", api.EnvVaultSkipVerify, err)
+		}
+
+		tlsConfig.Insecure = insecure
+	}
+
+	if err := config.ConfigureTLS(tlsConfig); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// vaultHeaders returns the request headers with the JSON object in VAULT_HEADERS of the source.
+func vaultHeaders(source EnvSource) (http.Header, error) {
+	headers := http.Header{api.RequestHeaderName: []string{"true"}}
+
+	v, ok := source.LookupEnv(api.EnvVaultHeaders)
+	if !ok || v == "" {
+		return headers, nil
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal([]byte(v), &values); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", api.EnvVaultHeaders, err)
+	}
+
+	for key, value := range values {
+		if strings.HasPrefix(key, "X-Vault-") {
+			return nil, fmt.Errorf("header %q of %s is for internal usage", key, api.EnvVaultHeaders)
+		}
+
+		headers.Add(key, value)
+	}
+
+	return headers, nil
+}
+
 // NewVaulterFromClient will create a Vaulter client based on input client.
 //
 // This function will try to choose live Vault instance from the Consul.
+//
+// Environment variables are read from the source in the context, see WithEnvSource.
 func NewVaulterFromClient(ctx context.Context, cl *api.Client) (Vaulter, error) {
 	if cl == nil {
 		return nil, ErrNoClient
 	}
 
+	source := internal.EnvSourceFromContext(ctx)
+
 	err := ErrNoClient
-	if v, _ := strconv.ParseBool(os.Getenv("VAULT_CONSUL_ADDR_DISABLE")); !v {
+	if v, _ := strconv.ParseBool(internal.GetEnvWithFallback(source, "VAULT_CONSUL_ADDR_DISABLE", "")); !v {
 		// Override vault address from consul
 		err = FetchVaultAddrFromConsul(ctx, cl, (&Consul{}).SearchLiveServices)
 		if err != nil && !errors.Is(err, ErrNoClient) {
@@ -139,28 +394,29 @@ func NewVaulterFromClient(ctx context.Context, cl *api.Client) (Vaulter, error)
 	// not get any address with environment value
 	if errors.Is(err, ErrNoClient) {
 		// check VAULT_ADDR and VAULT_AGENT_ADDR to not change vault address
-		if _, ok := os.LookupEnv("VAULT_ADDR"); ok {
-			return setAppRoleEnv(cl)
+		if _, ok := source.LookupEnv("VAULT_ADDR"); ok {
+			return setAppRoleEnv(cl, source)
 		}
 
-		if _, ok := os.LookupEnv("VAULT_AGENT_ADDR"); ok {
-			return setAppRoleEnv(cl)
+		if _, ok := source.LookupEnv("VAULT_AGENT_ADDR"); ok {
+			return setAppRoleEnv(cl, source)
 		}
 
 		return nil, fmt.Errorf("VAULT_ADDR, VAULT_AGENT_ADDR or CONSUL_HTTP_ADDR not found, err: %w", ErrNoClient)
 	}
 
-	return setAppRoleEnv(cl)
+	return setAppRoleEnv(cl, source)
 }
 
-func setAppRoleEnv(cl *api.Client) (Vaulter, error) {
-	roleID, roleSecret := os.Getenv(VaultRoleIDEnv), os.Getenv(VaultRoleSecretEnv)
+func setAppRoleEnv(cl *api.Client, source EnvSource) (Vaulter, error) {
+	roleID := internal.GetEnvWithFallback(source, VaultRoleIDEnv, "")
+	roleSecret := internal.GetEnvWithFallback(source, VaultRoleSecretEnv, "")
 	// Check only roleID as roleSecret can be empty in some cases.
 	if roleID != "" {
 		// Unset previous token to prevent any problems.
 		cl.ClearToken()
 
-		if err := SetAppRole(roleID, roleSecret)(cl); err != nil {
+		if err := setAppRole(source, roleID, roleSecret)(cl); err != nil {
 			return nil, err
 		}
 	}
@@ -224,8 +480,12 @@ func SetToken(token string) AuthOption {
 // Vault can also be setup to authenticate with role_id only
 // for this the secret id can be passed as blank.
 func SetAppRole(role, secret string) AuthOption {
+	return setAppRole(nil, role, secret)
+}
+
+func setAppRole(source EnvSource, role, secret string) AuthOption {
 	return func(c *api.Client) error {
-		path := internal.GetEnvWithFallback(VaultAppRoleBasePathEnv, VaultAppRoleBasePath)
+		path := internal.GetEnvWithFallback(source, VaultAppRoleBasePathEnv, VaultAppRoleBasePath)
 		resp, err := c.Logical().Write(path, map[string]interface{}{
 			"role_id":   role,
 			"secret_id": secret,
@@ -249,6 +509,12 @@ func (l *Vault) LoadWithContext(ctx context.Context, appName string, to interfac
 		return err
 	}
 
+	if l.Hierarchical {
+		if err := l.loadParents(ctx, appName, to); err != nil {
+			return err
+		}
+	}
+
 	return l.LoadFromReformat(ctx, []AdditionalPath{{Map: "", Name: appName}}, to)
 }
 
@@ -264,45 +530,41 @@ func (l *Vault) LoadGeneric(ctx context.Context, to interface{}) error {
 
 // LoadFromReformat loads secrets from Vault and load to the input struct 'to'.
 func (l *Vault) LoadFromReformat(ctx context.Context, paths []AdditionalPath, to interface{}) error {
+	return l.loadPaths(ctx, paths, to, true)
+}
+
+// loadPaths loads the paths in order, read errors are only logged if errCheck is false.
+func (l *Vault) loadPaths(ctx context.Context, paths []AdditionalPath, to interface{}, errCheck bool) error {
 	for _, path := range paths {
-		secretMap, err := l.loadSecretData(ctx, path.Name, true)
+		secretMap, err := l.loadSecretData(ctx, path.Name, errCheck)
 		if err != nil {
 			return err
 		}
 
-		if path.InnerPath != "" {
-			maps := strings.Split(path.InnerPath, "/")
-
-			for _, m := range maps {
-				var ok bool
-				secretMap, ok = secretMap[m].(map[string]interface{})
-				if !ok {
-					log.Ctx(ctx).Warn().Str("key", m).Msg("can't find key in secret data, leaving empty")
+		secretMap = path.reformat(ctx, secretMap)
 
-					break
-				}
-			}
+		if err := codec.MapDecoderWithSource(ctx, secretMap, to, VaultSecretTag, path.Name); err != nil {
+			//nolint:wrapcheck // not need
+			return err
 		}
+	}
 
-		if path.Map != "" {
-			maps := strings.Split(path.Map, "/")
-
-			mapDef := map[string]interface{}{}
-			mapRange := mapDef
-			for _, m := range maps {
-				if m == maps[len(maps)-1] {
-					mapRange[m] = secretMap
-					break
-				}
+	return nil
+}
 
-				mapRange[m] = map[string]interface{}{}
-				mapRange = mapRange[m].(map[string]interface{})
-			}
+// loadParents reads the secrets of the parents of appName, other applications under a parent are not listed.
+func (l *Vault) loadParents(ctx context.Context, appName string, to interface{}) error {
+	if err := l.EnsureClient(ctx); err != nil {
+		return err
+	}
 
-			secretMap = mapDef
+	for _, parent := range parentPaths(appName) {
+		secretMap, err := l.read(ctx, parent.Name, false)
+		if err != nil && !errors.Is(err, errUnusable) {
+			return err
 		}
 
-		if err := codec.MapDecoder(secretMap, to, VaultSecretTag); err != nil {
+		if err := codec.MapDecoderWithSource(ctx, secretMap, to, VaultSecretTag, parent.Name); err != nil {
 			//nolint:wrapcheck // not need
 			return err
 		}
@@ -351,7 +613,7 @@ func (l *Vault) loadSecretData(ctx context.Context, appName string, errCheck boo
 }
 
 func (l *Vault) list(ctx context.Context, appName string) (map[string]interface{}, error) {
-	secretBasePath := internal.GetEnvWithFallback(VaultSecretBasePathEnv, VaultSecretBasePath)
+	secretBasePath := internal.GetEnvWithFallback(internal.EnvSourceFromContext(ctx), VaultSecretBasePathEnv, VaultSecretBasePath)
 	appNameMeta := path.Join(secretBasePath, "metadata", appName)
 
 	pathSecret, _ := l.Client.List(appNameMeta)
@@ -381,7 +643,7 @@ func (l *Vault) list(ctx context.Context, appName string) (map[string]interface{
 }
 
 func (l *Vault) read(ctx context.Context, appName string, errCheck bool) (map[string]interface{}, error) {
-	secretBasePath := internal.GetEnvWithFallback(VaultSecretBasePathEnv, VaultSecretBasePath)
+	secretBasePath := internal.GetEnvWithFallback(internal.EnvSourceFromContext(ctx), VaultSecretBasePathEnv, VaultSecretBasePath)
 	appNameData := path.Join(secretBasePath, "data", appName)
 
 	pathSecret, err := l.Client.Read(appNameData)
@@ -2,6 +2,7 @@ package loader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,8 @@ import (
 	"github.com/hashicorp/vault/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/worldline-go/igconfig/codec"
 )
 
 type inner struct {
@@ -569,3 +572,109 @@ func TestVault_SecretPath(t *testing.T) {
 		}()
 	}
 }
+
+func TestVault_Hierarchical(t *testing.T) {
+	t.Setenv(VaultSecretBasePathEnv, VaultSecretBasePath)
+
+	type config struct {
+		Team   string `secret:"team"`
+		Domain string `secret:"domain"`
+		DBPass string `secret:"db_pass"`
+	}
+
+	mock := deniedVaultMock{
+		VaultMock: VaultMock{data: map[string]interface{}{
+			"data/payments":                   map[string]interface{}{"team": "payments", "domain": "payments", "db_pass": "team"},
+			"data/payments/settlement/worker": map[string]interface{}{"db_pass": "worker"},
+		}},
+		denied: "data/payments/settlement",
+	}
+
+	var c config
+
+	require.NoError(t, (&Vault{Client: mock, Hierarchical: true}).Load("payments/settlement/worker", &c))
+	assert.Equal(t, config{Team: "payments", Domain: "payments", DBPass: "worker"}, c)
+
+	// secrets of other applications under a parent are not listed
+	mock.data["data/payments/refunds"] = map[string]interface{}{"team": "refunds", "api_key": "refunds-secret"}
+	mock.list = map[string][]interface{}{
+		"metadata/payments":            {"refunds", "settlement/"},
+		"metadata/payments/settlement": {"worker"},
+	}
+
+	delete(mock.data, "data/payments")
+
+	defer func(strict codec.StrictMode) { codec.Strict = strict }(codec.Strict)
+
+	codec.Strict = codec.StrictError
+	c = config{}
+
+	require.NoError(t, (&Vault{Client: mock, Hierarchical: true}).Load("payments/settlement/worker", &c))
+	assert.Equal(t, config{DBPass: "worker"}, c)
+
+	// parents are not loaded by default
+	c = config{}
+
+	require.NoError(t, (&Vault{Client: mock}).Load("payments/settlement/worker", &c))
+	assert.Equal(t, config{DBPass: "worker"}, c)
+}
+
+// deniedVaultMock returns an error for the denied path like a missing policy.
+type deniedVaultMock struct {
+	VaultMock
+	denied string
+}
+
+func (v deniedVaultMock) Read(path string) (*api.Secret, error) {
+	if strings.TrimPrefix(strings.TrimPrefix(path, VaultSecretBasePath), "/") == v.denied {
+		return nil, errors.New("permission denied")
+	}
+
+	return v.VaultMock.Read(path)
+}
+
+func TestVaultClient_EnvSource(t *testing.T) {
+	for _, key := range []string{api.EnvVaultAddress, api.EnvVaultToken, api.EnvVaultNamespace, api.EnvVaultHeaders, api.EnvVaultMaxRetries} {
+		t.Setenv(key, "")
+	}
+
+	cl, err := vaultClient(EnvMap{
+		api.EnvVaultAddress:       "https://vault.example.com:8200",
+		api.EnvVaultToken:         "source-token",
+		api.EnvVaultNamespace:     "team",
+		api.EnvVaultHeaders:       `{"X-Team": "payments"}`,
+		api.EnvVaultMaxRetries:    "5",
+		api.EnvVaultClientTimeout: "10",
+		api.EnvVaultSkipVerify:    "true",
+		api.EnvVaultTLSServerName: "vault",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://vault.example.com:8200", cl.Address())
+	assert.Equal(t, "source-token", cl.Token())
+	assert.Equal(t, "team", cl.Namespace())
+	assert.Equal(t, "payments", cl.Headers().Get("X-Team"))
+	assert.Equal(t, "true", cl.Headers().Get(api.RequestHeaderName))
+
+	config := cl.CloneConfig()
+	assert.Equal(t, 5, config.MaxRetries)
+	assert.Equal(t, 10*time.Second, config.Timeout)
+	assert.True(t, config.TLSConfig().InsecureSkipVerify)
+	assert.Equal(t, "vault", config.TLSConfig().ServerName)
+
+	// process environment is not used
+	t.Setenv(api.EnvVaultToken, "os-token")
+	t.Setenv(api.EnvVaultNamespace, "os-namespace")
+	t.Setenv(api.EnvVaultMaxRetries, "9")
+
+	cl, err = vaultClient(EnvMap{})
+	require.NoError(t, err)
+
+	assert.Empty(t, cl.Token())
+	assert.Empty(t, cl.Namespace())
+	assert.Equal(t, api.DefaultAddress, cl.Address())
+	assert.Equal(t, 2, cl.CloneConfig().MaxRetries)
+
+	_, err = vaultClient(EnvMap{api.EnvVaultHeaders: `{"X-Vault-Token": "x"}`})
+	assert.Error(t, err)
+}